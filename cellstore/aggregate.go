@@ -0,0 +1,96 @@
+package cellstore
+
+import (
+	"context"
+
+	"github.com/golang/geo/s2"
+)
+
+// Stats contain aggregated entry statistics.
+type Stats struct {
+	// Count is the number of entries.
+	Count int
+	// ValueBytes is the total size of all values in bytes.
+	ValueBytes int
+	// Reduced is the result of the Reducer, if one was given.
+	Reduced float64
+}
+
+// Reducer reduces values to a single number, e.g. by
+// summing up a weight which is encoded in each value.
+// Please note that values must not be retained.
+type Reducer func(acc float64, value []byte) float64
+
+// Aggregate walks all entries within the region and aggregates them by
+// their parent cell at the given level. A nil region aggregates the whole
+// store. An optional reducer may be used to reduce values.
+func (r *Reader) Aggregate(region s2.Region, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	return r.AggregateContext(context.Background(), region, level, reduce)
+}
+
+// AggregateContext is like Aggregate but aborts with ctx.Err() once
+// the context is cancelled.
+func (r *Reader) AggregateContext(ctx context.Context, region s2.Region, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	if region == nil {
+		return r.AggregateRangeContext(ctx, 0, ^s2.CellID(0), level, reduce)
+	}
+
+	if level < 0 || level > s2.MaxLevel {
+		return nil, errInvalidLevel
+	}
+
+	qs := r.newQueryStats("Aggregate")
+	defer r.observe(qs)
+
+	agg := make(map[s2.CellID]Stats)
+	if err := r.scanRegion(ctx, region, 0, qs, aggregator(agg, level, reduce, qs)); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// AggregateRange walks all entries between min and max (both inclusive)
+// and aggregates them by their parent cell at the given level.
+// An optional reducer may be used to reduce values.
+func (r *Reader) AggregateRange(min, max s2.CellID, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	return r.AggregateRangeContext(context.Background(), min, max, level, reduce)
+}
+
+// AggregateRangeContext is like AggregateRange but aborts with ctx.Err()
+// once the context is cancelled.
+func (r *Reader) AggregateRangeContext(ctx context.Context, min, max s2.CellID, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	if level < 0 || level > s2.MaxLevel {
+		return nil, errInvalidLevel
+	}
+
+	qs := r.newQueryStats("Aggregate")
+	defer r.observe(qs)
+
+	agg := make(map[s2.CellID]Stats)
+	if err := r.scanRange(ctx, min, max, qs, aggregator(agg, level, reduce, qs)); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+func aggregator(agg map[s2.CellID]Stats, level int, reduce Reducer, qs *QueryStats) func(s2.CellID, []byte) error {
+	return func(cellID s2.CellID, value []byte) error {
+		if qs != nil {
+			qs.EntriesReturned++
+		}
+
+		parent := cellID
+		if cellID.Level() > level {
+			parent = cellID.Parent(level)
+		}
+
+		stats := agg[parent]
+		stats.Count++
+		stats.ValueBytes += len(value)
+		if reduce != nil {
+			stats.Reduced = reduce(stats.Reduced, value)
+		}
+		agg[parent] = stats
+		return nil
+	}
+}
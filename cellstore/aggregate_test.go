@@ -0,0 +1,79 @@
+package cellstore_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Aggregate", func() {
+	var subject *cellstore.Reader
+
+	london1 := cellIDFromDegrees(51.50, -0.12)
+	london2 := cellIDFromDegrees(51.51, -0.13)
+	london3 := cellIDFromDegrees(51.49, -0.10)
+	paris1 := cellIDFromDegrees(48.86, 2.35)
+	paris2 := cellIDFromDegrees(48.85, 2.34)
+
+	sum := func(acc float64, value []byte) float64 {
+		n, _ := strconv.ParseFloat(string(value), 64)
+		return acc + n
+	}
+
+	BeforeEach(func() {
+		subject = seedStore(map[s2.CellID]string{
+			london1: "1",
+			london2: "20",
+			london3: "300",
+			paris1:  "4000",
+			paris2:  "50000",
+		})
+	})
+
+	It("should aggregate all", func() {
+		Expect(subject.Aggregate(nil, 6, nil)).To(Equal(map[s2.CellID]cellstore.Stats{
+			london1.Parent(6): {Count: 3, ValueBytes: 6},
+			paris1.Parent(6):  {Count: 2, ValueBytes: 9},
+		}))
+		Expect(subject.Aggregate(nil, 6, sum)).To(Equal(map[s2.CellID]cellstore.Stats{
+			london1.Parent(6): {Count: 3, ValueBytes: 6, Reduced: 321},
+			paris1.Parent(6):  {Count: 2, ValueBytes: 9, Reduced: 54000},
+		}))
+		Expect(subject.Aggregate(nil, 0, nil)).To(Equal(map[s2.CellID]cellstore.Stats{
+			s2.CellIDFromFace(2): {Count: 5, ValueBytes: 15},
+		}))
+	})
+
+	It("should aggregate within regions", func() {
+		region := s2.CapFromCenterAngle(s2.PointFromLatLng(s2.LatLngFromDegrees(51.5, -0.12)), s1.Degree)
+		Expect(subject.Aggregate(region, 6, sum)).To(Equal(map[s2.CellID]cellstore.Stats{
+			london1.Parent(6): {Count: 3, ValueBytes: 6, Reduced: 321},
+		}))
+	})
+
+	It("should aggregate ranges", func() {
+		Expect(subject.AggregateRange(paris1.Parent(6).RangeMin(), paris1.Parent(6).RangeMax(), 30, nil)).To(Equal(map[s2.CellID]cellstore.Stats{
+			paris1: {Count: 1, ValueBytes: 4},
+			paris2: {Count: 1, ValueBytes: 5},
+		}))
+	})
+
+	It("should reject invalid levels", func() {
+		_, err := subject.Aggregate(nil, 31, nil)
+		Expect(err).To(MatchError(`cellstore: invalid level`))
+	})
+
+	It("should not allocate per entry", func() {
+		subject = seedInMem(1000)
+		allocs := testing.AllocsPerRun(10, func() {
+			_, _ = subject.Aggregate(nil, 20, nil)
+		})
+		// allocations are per block, not per entry
+		Expect(allocs).To(BeNumerically("<", 5*subject.NumBlocks()))
+	})
+})
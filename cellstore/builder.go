@@ -0,0 +1,161 @@
+package cellstore
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/golang/geo/s2"
+)
+
+// MergeFunc merges multiple values which were appended for the same cell
+// into a single value by appending them to dst.
+type MergeFunc func(dst []byte, values [][]byte) []byte
+
+// JoinValues returns a MergeFunc which concatenates values, separated by sep.
+func JoinValues(sep []byte) MergeFunc {
+	return func(dst []byte, values [][]byte) []byte {
+		for i, v := range values {
+			if i != 0 {
+				dst = append(dst, sep...)
+			}
+			dst = append(dst, v...)
+		}
+		return dst
+	}
+}
+
+// BuilderOptions define Builder specific options.
+type BuilderOptions struct {
+	SorterOptions
+
+	// Merge merges values which were appended for the same cell.
+	// Default: JoinValues(nil)
+	Merge MergeFunc
+}
+
+func (o *BuilderOptions) norm() *BuilderOptions {
+	var oo BuilderOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.Merge == nil {
+		oo.Merge = JoinValues(nil)
+	}
+	return &oo
+}
+
+// Builder accepts entries in arbitrary order and writes them
+// sorted by cell ID.
+type Builder struct {
+	s     *Sorter
+	merge MergeFunc
+	buf   []byte
+}
+
+// NewBuilder inits a new builder.
+func NewBuilder(o *BuilderOptions) *Builder {
+	o = o.norm()
+	return &Builder{
+		s:     NewSorter(&o.SorterOptions),
+		merge: o.Merge,
+	}
+}
+
+// Append appends a cell to the builder.
+func (b *Builder) Append(cellID s2.CellID, data []byte) error {
+	return b.s.Append(cellID, data)
+}
+
+// AppendCellUnion normalises cu and appends all of its cells
+// with the same value, to be written by BuildRanges. Cells of different
+// unions may only overlap if they are equal, in which case their values
+// are merged. Partially overlapping unions, e.g. a cell and one of its
+// descendants, cause BuildRanges to fail.
+func (b *Builder) AppendCellUnion(cu s2.CellUnion, data []byte) error {
+	cu = append(s2.CellUnion(nil), cu...)
+	for _, cellID := range cu {
+		if !cellID.IsValid() {
+			return errInvalidCellID
+		}
+	}
+	cu.Normalize()
+
+	for _, cellID := range cu {
+		if err := b.s.Append(cellID, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildRanges sorts all appended cells and writes them to w as intervals.
+// Adjacent cells with equal values are merged into a single interval.
+// It returns an error if appended cells overlap at different levels.
+// Please note that the writer is not closed.
+func (b *Builder) BuildRanges(w *RangeWriter) error {
+	iter, err := b.s.Sort()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	var min, max s2.CellID
+	var value []byte
+	for {
+		cellID, values, err := iter.NextEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		b.buf = b.merge(b.buf[:0], values)
+		if max != 0 && max.Next() == cellID.RangeMin() && bytes.Equal(value, b.buf) {
+			max = cellID.RangeMax()
+			continue
+		}
+
+		if max != 0 {
+			if err := w.AppendRange(min, max, value); err != nil {
+				return err
+			}
+		}
+		min, max = cellID.RangeMin(), cellID.RangeMax()
+		value = append(value[:0], b.buf...)
+	}
+
+	if max != 0 {
+		return w.AppendRange(min, max, value)
+	}
+	return nil
+}
+
+// Build sorts all appended entries and writes them to w.
+// Please note that the writer is not closed.
+func (b *Builder) Build(w *Writer) error {
+	iter, err := b.s.Sort()
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for {
+		cellID, values, err := iter.NextEntry()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		b.buf = b.merge(b.buf[:0], values)
+		if err := w.Append(uint64(cellID), b.buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the builder and releases all resources.
+func (b *Builder) Close() error {
+	return b.s.Close()
+}
@@ -0,0 +1,45 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("Builder", func() {
+	var subject *cellstore.Builder
+
+	BeforeEach(func() {
+		subject = cellstore.NewBuilder(&cellstore.BuilderOptions{
+			Merge: cellstore.JoinValues([]byte(",")),
+		})
+	})
+
+	AfterEach(func() {
+		_ = subject.Close()
+	})
+
+	It("should build", func() {
+		Expect(subject.Append(seedCellID+8, []byte("data1"))).To(Succeed())
+		Expect(subject.Append(seedCellID, []byte("data2"))).To(Succeed())
+		Expect(subject.Append(seedCellID+8, []byte("data3"))).To(Succeed())
+		Expect(subject.Append(seedCellID+16, []byte("data4"))).To(Succeed())
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, nil)
+		Expect(subject.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(seedCellID)).To(Equal([]byte("data2")))
+		Expect(r.Get(seedCellID + 8)).To(Equal([]byte("data1,data3")))
+		Expect(r.Get(seedCellID + 16)).To(Equal([]byte("data4")))
+	})
+
+	It("should reject invalid cell IDs", func() {
+		Expect(subject.Append(seedCellID+1, []byte("data1"))).To(MatchError(`cellstore: invalid cell ID`))
+	})
+})
@@ -2,9 +2,38 @@ package cellstore
 
 import (
 	"errors"
+
+	"github.com/bsm/sntable"
 )
 
+// ErrNotFound is returned by lookups when no entry can be found.
+var ErrNotFound = sntable.ErrNotFound
+
 var (
+	errBadIndex      = errors.New("cellstore: bad index")
+	errBadMeta       = errors.New("cellstore: bad metadata")
+	errGroupedToken  = errors.New("cellstore: grouped queries cannot be paginated")
+	errBadRange      = errors.New("cellstore: bad range")
+	errClosed        = errors.New("cellstore: reader is closed")
 	errInvalidCellID = errors.New("cellstore: invalid cell ID")
+	errInvalidLevel  = errors.New("cellstore: invalid level")
+	errInvalidRange  = errors.New("cellstore: invalid range")
+	errInvalidToken  = errors.New("cellstore: invalid token")
 	errReleased      = errors.New("cellstore: already released")
+	errStopScan      = errors.New("cellstore: scan stopped")
+
+	errOverlappingRanges = errors.New("cellstore: ranges overlap or are out of order")
+
+	errDuplicateLayer = errors.New("cellstore: duplicate layer name")
+	errLayerOpen      = errors.New("cellstore: layer is still open")
+	errTableClosed    = errors.New("cellstore: table is closed")
+	errUnknownLayer   = errors.New("cellstore: unknown layer")
+
+	errBadContentRange = errors.New("cellstore: bad HTTP content range")
+	errNegativeOffset  = errors.New("cellstore: negative offset")
+	errNoRangeSupport  = errors.New("cellstore: HTTP server does not support range requests")
+	errRemoteChanged   = errors.New("cellstore: remote file has changed")
+
+	errInvalidExportFormat = errors.New("cellstore: invalid export format")
+	errInvalidUTF8         = errors.New("cellstore: value is not valid UTF-8")
 )
@@ -24,6 +24,13 @@ func TestSuite(t *testing.T) {
 const seedCellID = 1317624576600000001
 
 func seedInMem(numRecords int) *cellstore.Reader {
+	data := seedBytes(numRecords)
+	r, err := cellstore.NewReader(bytes.NewReader(data), int64(len(data)))
+	Expect(err).NotTo(HaveOccurred())
+	return r
+}
+
+func seedBytes(numRecords int) []byte {
 	buf := new(bytes.Buffer)
 	rnd := rand.New(rand.NewSource(1))
 	val := make([]byte, 128)
@@ -38,12 +45,33 @@ func seedInMem(numRecords int) *cellstore.Reader {
 		Expect(w.Append(uint64(cellID), val)).To(Succeed())
 	}
 	Expect(w.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func seedStore(entries map[s2.CellID]string) *cellstore.Reader {
+	b := cellstore.NewBuilder(nil)
+	defer b.Close()
+
+	for cellID, value := range entries {
+		Expect(b.Append(cellID, []byte(value))).To(Succeed())
+	}
+
+	buf := new(bytes.Buffer)
+	w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{
+		WriterOptions: sntable.WriterOptions{BlockSize: 256, BlockRestartInterval: 4},
+	})
+	Expect(b.Build(w)).To(Succeed())
+	Expect(w.Close()).To(Succeed())
 
 	r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
 	Expect(err).NotTo(HaveOccurred())
 	return r
 }
 
+func cellIDFromDegrees(lat, lng float64) s2.CellID {
+	return s2.CellIDFromLatLng(s2.LatLngFromDegrees(lat, lng))
+}
+
 func createSeeds(numRecords int, compression sntable.Compression) (string, error) {
 	f, err := os.CreateTemp("", "cellstore-bench")
 	if err != nil {
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bsm/geokit/cellstore"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+// pointFunc is called for each point parsed from the input.
+type pointFunc func(ll s2.LatLng, payload []byte) error
+
+func runBuild(args []string) error {
+	var (
+		output      string
+		format      string
+		level       int
+		compression string
+		blockSize   int
+		restarts    int
+		sep         string
+		tempDir     string
+		fpRate      float64
+		contentHash bool
+		parallel    bool
+		csvOpts     csvOptions
+	)
+
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.StringVar(&output, "o", "", "output file (required)")
+	fs.StringVar(&format, "format", "", "input format, csv or geojson (default: derived from file extension)")
+	fs.IntVar(&level, "level", s2.MaxLevel, "cell level of the stored entries")
+	fs.StringVar(&compression, "compression", "snappy", "block compression, snappy or none")
+	fs.IntVar(&blockSize, "block-size", 4096, "minimum uncompressed block size in bytes")
+	fs.IntVar(&restarts, "restart-interval", 16, "number of keys between restart points")
+	fs.Float64Var(&fpRate, "filter-fp-rate", 0, "false-positive rate of per-block bloom filters, e.g. 0.01 (default: no filters)")
+	fs.BoolVar(&contentHash, "content-hash", true, "record a content hash in the file metadata")
+	fs.StringVar(&sep, "sep", "\n", "separator for multiple payloads in the same cell")
+	fs.BoolVar(&parallel, "parallel", false, "partition input by face and sort partitions concurrently")
+	fs.StringVar(&tempDir, "tmp", "", "temporary directory for sorting (default: os.TempDir())")
+	fs.IntVar(&csvOpts.LatCol, "lat-col", 0, "CSV column index of the latitude")
+	fs.IntVar(&csvOpts.LngCol, "lng-col", 1, "CSV column index of the longitude")
+	fs.IntVar(&csvOpts.PayloadCol, "payload-col", 2, "CSV column index of the payload")
+	fs.BoolVar(&csvOpts.Header, "header", false, "skip the first CSV row")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cellstore build -o output.cs [flags] [input files...]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Reads from STDIN if no input files are given.")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if output == "" {
+		fs.Usage()
+		return errors.New("missing output file")
+	}
+	if level < 0 || level > s2.MaxLevel {
+		return fmt.Errorf("invalid level %d", level)
+	}
+
+	if fpRate < 0 || fpRate >= 1 {
+		return fmt.Errorf("invalid filter false-positive rate %v", fpRate)
+	}
+
+	wopt := &cellstore.WriterOptions{FilterFPRate: fpRate, ContentHash: contentHash}
+	wopt.BlockSize = blockSize
+	wopt.BlockRestartInterval = restarts
+	switch compression {
+	case "snappy":
+		wopt.Compression = sntable.SnappyCompression
+	case "none":
+		wopt.Compression = sntable.NoCompression
+	default:
+		return fmt.Errorf("invalid compression %q", compression)
+	}
+
+	bopt := cellstore.BuilderOptions{
+		SorterOptions: cellstore.SorterOptions{TempDir: tempDir},
+		Merge:         cellstore.JoinValues([]byte(sep)),
+	}
+
+	var builder interface {
+		Append(s2.CellID, []byte) error
+		Build(*cellstore.Writer) error
+		Close() error
+	}
+	if parallel {
+		builder = cellstore.NewPartitionedBuilder(&cellstore.PartitionedBuilderOptions{BuilderOptions: bopt})
+	} else {
+		builder = cellstore.NewBuilder(&bopt)
+	}
+	defer builder.Close()
+
+	appendPoint := func(ll s2.LatLng, payload []byte) error {
+		if !ll.IsValid() {
+			return fmt.Errorf("invalid coordinates %s", ll)
+		}
+		return builder.Append(s2.CellIDFromLatLng(ll).Parent(level), payload)
+	}
+
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		if err := readInput(os.Stdin, format, &csvOpts, appendPoint); err != nil {
+			return fmt.Errorf("STDIN: %w", err)
+		}
+	}
+	for _, name := range inputs {
+		if err := readFile(name, format, &csvOpts, appendPoint); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := cellstore.NewWriterWithOptions(f, wopt)
+	if err := builder.Build(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func readFile(name, format string, csvOpts *csvOptions, fn pointFunc) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".geojson", ".json", ".ndjson":
+			format = "geojson"
+		}
+	}
+	return readInput(f, format, csvOpts, fn)
+}
+
+func readInput(r io.Reader, format string, csvOpts *csvOptions, fn pointFunc) error {
+	switch format {
+	case "", "csv":
+		return readCSV(r, csvOpts, fn)
+	case "geojson":
+		return readGeoJSON(r, fn)
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+}
+
+// --------------------------------------------------------------------
+
+type csvOptions struct {
+	LatCol, LngCol, PayloadCol int
+	Header                     bool
+}
+
+func readCSV(r io.Reader, o *csvOptions, fn pointFunc) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if row == 1 && o.Header {
+			continue
+		}
+
+		lat, err := parseCSVFloat(rec, o.LatCol)
+		if err != nil {
+			return fmt.Errorf("row %d: invalid latitude: %w", row, err)
+		}
+		lng, err := parseCSVFloat(rec, o.LngCol)
+		if err != nil {
+			return fmt.Errorf("row %d: invalid longitude: %w", row, err)
+		}
+
+		var payload []byte
+		if o.PayloadCol > -1 && o.PayloadCol < len(rec) {
+			payload = []byte(rec[o.PayloadCol])
+		}
+		if err := fn(s2.LatLngFromDegrees(lat, lng), payload); err != nil {
+			return fmt.Errorf("row %d: %w", row, err)
+		}
+	}
+}
+
+func parseCSVFloat(rec []string, col int) (float64, error) {
+	if col < 0 || col >= len(rec) {
+		return 0, fmt.Errorf("missing column %d", col)
+	}
+	return strconv.ParseFloat(strings.TrimSpace(rec[col]), 64)
+}
+
+// --------------------------------------------------------------------
+
+type geoJSONFeature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+// readGeoJSON reads Point features from a FeatureCollection, a single Feature
+// or a sequence of newline-delimited Features. Features of collections are
+// streamed, without reading the whole collection into memory.
+func readGeoJSON(r io.Reader, fn pointFunc) error {
+	dec := json.NewDecoder(r)
+	buf := new(bytes.Buffer)
+	pos := 0
+
+	handle := func(feat *geoJSONFeature) error {
+		pos++
+
+		if feat.Type != "Feature" {
+			return fmt.Errorf("feature %d: invalid type %q", pos, feat.Type)
+		}
+		if feat.Geometry == nil || feat.Geometry.Type != "Point" {
+			return fmt.Errorf("feature %d: unsupported geometry", pos)
+		}
+		if len(feat.Geometry.Coordinates) < 2 {
+			return fmt.Errorf("feature %d: invalid coordinates", pos)
+		}
+
+		buf.Reset()
+		if len(feat.Properties) != 0 && string(feat.Properties) != "null" {
+			if err := json.Compact(buf, feat.Properties); err != nil {
+				return fmt.Errorf("feature %d: %w", pos, err)
+			}
+		}
+
+		ll := s2.LatLngFromDegrees(feat.Geometry.Coordinates[1], feat.Geometry.Coordinates[0])
+		if err := fn(ll, buf.Bytes()); err != nil {
+			return fmt.Errorf("feature %d: %w", pos, err)
+		}
+		return nil
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if tok != json.Delim('{') {
+			return fmt.Errorf("unexpected token %v", tok)
+		}
+
+		// collect top-level attributes, stream features
+		var feat geoJSONFeature
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+
+			switch tok {
+			case "type":
+				err = dec.Decode(&feat.Type)
+			case "geometry":
+				err = dec.Decode(&feat.Geometry)
+			case "properties":
+				err = dec.Decode(&feat.Properties)
+			case "features":
+				err = decodeGeoJSONFeatures(dec, handle)
+			default:
+				err = dec.Decode(new(json.RawMessage))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+
+		if feat.Type != "FeatureCollection" {
+			if err := handle(&feat); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func decodeGeoJSONFeatures(dec *json.Decoder, handle func(*geoJSONFeature) error) error {
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("unexpected token %v", tok)
+	}
+
+	for dec.More() {
+		var feat geoJSONFeature
+		if err := dec.Decode(&feat); err != nil {
+			return err
+		}
+		if err := handle(&feat); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
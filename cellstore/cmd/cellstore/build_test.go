@@ -0,0 +1,120 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("build", func() {
+	type point struct {
+		LatLng  string
+		Payload string
+	}
+
+	collect := func(res *[]point) pointFunc {
+		return func(ll s2.LatLng, payload []byte) error {
+			*res = append(*res, point{LatLng: ll.String(), Payload: string(payload)})
+			return nil
+		}
+	}
+
+	It("should read CSV", func() {
+		var res []point
+		Expect(readCSV(strings.NewReader("lat,lng,name\n51.5,-0.12,London\n48.86,2.35,Paris\n"), &csvOptions{
+			LatCol: 0, LngCol: 1, PayloadCol: 2, Header: true,
+		}, collect(&res))).To(Succeed())
+		Expect(res).To(Equal([]point{
+			{LatLng: "[51.5000000, -0.1200000]", Payload: "London"},
+			{LatLng: "[48.8600000, 2.3500000]", Payload: "Paris"},
+		}))
+
+		err := readCSV(strings.NewReader("51.5,x,London\n"), &csvOptions{LngCol: 1}, collect(&res))
+		Expect(err).To(MatchError(`row 1: invalid longitude: strconv.ParseFloat: parsing "x": invalid syntax`))
+	})
+
+	It("should read GeoJSON", func() {
+		var res []point
+		Expect(readGeoJSON(strings.NewReader(`{
+			"type": "FeatureCollection",
+			"features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [-0.12, 51.5]}, "properties": {"name": "London"}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [2.35, 48.86]}, "properties": null}
+			]
+		}`), collect(&res))).To(Succeed())
+		Expect(res).To(Equal([]point{
+			{LatLng: "[51.5000000, -0.1200000]", Payload: `{"name":"London"}`},
+			{LatLng: "[48.8600000, 2.3500000]", Payload: ""},
+		}))
+
+		res = res[:0]
+		Expect(readGeoJSON(strings.NewReader(
+			`{"type": "Feature", "properties": {"id": 1}, "geometry": {"type": "Point", "coordinates": [-0.12, 51.5]}}`+"\n"+
+				`{"type": "Feature", "properties": {"id": 2}, "geometry": {"type": "Point", "coordinates": [2.35, 48.86]}}`+"\n",
+		), collect(&res))).To(Succeed())
+		Expect(res).To(Equal([]point{
+			{LatLng: "[51.5000000, -0.1200000]", Payload: `{"id":1}`},
+			{LatLng: "[48.8600000, 2.3500000]", Payload: `{"id":2}`},
+		}))
+
+		err := readGeoJSON(strings.NewReader(`{"type": "Feature", "geometry": {"type": "LineString", "coordinates": []}}`), collect(&res))
+		Expect(err).To(MatchError(`feature 1: unsupported geometry`))
+	})
+
+	It("should build stores", func() {
+		dir := GinkgoT().TempDir()
+		input := filepath.Join(dir, "input.csv")
+		output := filepath.Join(dir, "output.cs")
+		Expect(os.WriteFile(input, []byte("51.5,-0.12,a\n51.5,-0.12,b\n48.86,2.35,c\n"), 0o644)).To(Succeed())
+		Expect(runBuild([]string{"-o", output, "-level", "20", "-sep", "|", input})).To(Succeed())
+
+		r, closer, err := openReader(output)
+		Expect(err).NotTo(HaveOccurred())
+		defer closer.Close()
+
+		cellID := s2.CellIDFromLatLng(s2.LatLngFromDegrees(51.5, -0.12)).Parent(20)
+		Expect(r.Get(uint64(cellID))).To(Equal([]byte("a|b")))
+		Expect(r.ContentHash()).To(HaveLen(32))
+	})
+
+	It("should build reproducibly", func() {
+		dir := GinkgoT().TempDir()
+		input := filepath.Join(dir, "input.csv")
+		Expect(os.WriteFile(input, []byte("51.5,-0.12,b\n48.86,2.35,c\n51.5,-0.12,a\n"), 0o644)).To(Succeed())
+		Expect(runBuild([]string{"-o", filepath.Join(dir, "a.cs"), "-sep", "|", input})).To(Succeed())
+		Expect(runBuild([]string{"-o", filepath.Join(dir, "b.cs"), "-sep", "|", input})).To(Succeed())
+		Expect(runBuild([]string{"-o", filepath.Join(dir, "c.cs"), "-sep", "|", "-parallel", input})).To(Succeed())
+
+		a, err := os.ReadFile(filepath.Join(dir, "a.cs"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(filepath.Join(dir, "b.cs"))).To(Equal(a))
+		Expect(os.ReadFile(filepath.Join(dir, "c.cs"))).To(Equal(a))
+
+		r, closer, err := openReader(filepath.Join(dir, "a.cs"))
+		Expect(err).NotTo(HaveOccurred())
+		defer closer.Close()
+		Expect(r.Get(uint64(s2.CellIDFromLatLng(s2.LatLngFromDegrees(51.5, -0.12))))).To(Equal([]byte("b|a")))
+	})
+
+	It("should build stores with filters", func() {
+		dir := GinkgoT().TempDir()
+		input := filepath.Join(dir, "input.csv")
+		output := filepath.Join(dir, "output.cs")
+		Expect(os.WriteFile(input, []byte("51.5,-0.12,a\n48.86,2.35,c\n"), 0o644)).To(Succeed())
+		Expect(runBuild([]string{"-o", output, "-filter-fp-rate", "0.01", input})).To(Succeed())
+		Expect(runBuild([]string{"-o", output, "-filter-fp-rate", "1.5", input})).To(MatchError(`invalid filter false-positive rate 1.5`))
+
+		r, closer, err := openReader(output)
+		Expect(err).NotTo(HaveOccurred())
+		defer closer.Close()
+
+		Expect(r.Get(uint64(s2.CellIDFromLatLng(s2.LatLngFromDegrees(48.86, 2.35))))).To(Equal([]byte("c")))
+		_, err = r.Get(uint64(s2.CellIDFromLatLng(s2.LatLngFromDegrees(48.86, 2.36))))
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+	})
+})
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/bsm/geokit/cellstore"
+	"github.com/golang/geo/s2"
+)
+
+func runDiff(args []string) error {
+	var (
+		output  string
+		level   int
+		top     int
+		entries bool
+	)
+
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.StringVar(&output, "o", "", "output file (default: STDOUT)")
+	fs.IntVar(&level, "level", 8, "level of the parent cells to summarise differences by (1-30)")
+	fs.IntVar(&top, "top", 20, "number of parent cells to list, ordered by number of differences (0: all)")
+	fs.BoolVar(&entries, "entries", false, "list each difference")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cellstore diff [flags] old.cs new.cs")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected exactly two stores")
+	}
+	if level < 1 || level > s2.MaxLevel {
+		return fmt.Errorf("invalid level %d", level)
+	}
+
+	a, aCloser, err := openReader(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer aCloser.Close()
+
+	b, bCloser, err := openReader(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer bCloser.Close()
+
+	if output == "" {
+		return writeDiff(os.Stdout, a, b, level, top, entries)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := writeDiff(f, a, b, level, top, entries); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeDiff(w io.Writer, a, b *cellstore.Reader, level, top int, entries bool) error {
+	bw := bufio.NewWriter(w)
+
+	o := &cellstore.DiffOptions{Level: level}
+	if entries {
+		o.OnDiff = func(kind cellstore.DiffKind, cellID s2.CellID, old, new []byte) error {
+			_, err := fmt.Fprintf(bw, "%s\t%s\t%q\t%q\n", kind, cellID.ToToken(), old, new)
+			return err
+		}
+	}
+
+	sum, err := cellstore.Diff(a, b, o)
+	if err != nil {
+		return err
+	}
+	if entries {
+		fmt.Fprintln(bw)
+	}
+
+	fmt.Fprintf(bw, "added\t%d\nremoved\t%d\nchanged\t%d\nunchanged\t%d\n", sum.Added, sum.Removed, sum.Changed, sum.Unchanged)
+
+	cells := make([]s2.CellID, 0, len(sum.Cells))
+	for cellID := range sum.Cells {
+		cells = append(cells, cellID)
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if ti, tj := sum.Cells[cells[i]].Total(), sum.Cells[cells[j]].Total(); ti != tj {
+			return ti > tj
+		}
+		return cells[i] < cells[j]
+	})
+	if top > 0 && len(cells) > top {
+		cells = cells[:top]
+	}
+
+	if len(cells) != 0 {
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "cell\tlevel\tlat\tlng\tadded\tremoved\tchanged")
+		for _, cellID := range cells {
+			cnt := sum.Cells[cellID]
+			ll := cellID.LatLng()
+			fmt.Fprintf(bw, "%s\t%d\t%.5f\t%.5f\t%d\t%d\t%d\n", cellID.ToToken(), cellID.Level(), ll.Lat.Degrees(), ll.Lng.Degrees(), cnt.Added, cnt.Removed, cnt.Changed)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+var _ = Describe("diff", func() {
+	It("should diff stores", func() {
+		dir := GinkgoT().TempDir()
+		for name, data := range map[string]string{
+			"a.csv": "51.5,-0.12,London\n48.86,2.35,Paris\n",
+			"b.csv": "51.5,-0.12,London\n48.86,2.35,Paris 2\n52.52,13.4,Berlin\n",
+		} {
+			input := filepath.Join(dir, name)
+			Expect(os.WriteFile(input, []byte(data), 0o644)).To(Succeed())
+			Expect(runBuild([]string{"-o", input[:len(input)-4] + ".cs", "-level", "20", input})).To(Succeed())
+		}
+
+		output := filepath.Join(dir, "diff.txt")
+		Expect(runDiff([]string{"-o", output, "-level", "4", "-entries", filepath.Join(dir, "a.cs"), filepath.Join(dir, "b.cs")})).To(Succeed())
+		Expect(os.ReadFile(output)).To(Equal([]byte(
+			"added\t47a851de6f3\t\"\"\t\"Berlin\"\n" +
+				"changed\t47e66e1ea23\t\"Paris\"\t\"Paris 2\"\n" +
+				"\n" +
+				"added\t1\nremoved\t0\nchanged\t1\nunchanged\t1\n" +
+				"\n" +
+				"cell\tlevel\tlat\tlng\tadded\tremoved\tchanged\n" +
+				"47b\t4\t52.26403\t10.17551\t1\t0\t0\n" +
+				"47f\t4\t47.41780\t2.67997\t0\t0\t1\n",
+		)))
+
+		Expect(runDiff([]string{filepath.Join(dir, "a.cs")})).To(MatchError(`expected exactly two stores`))
+		Expect(runDiff([]string{"-level", "0", filepath.Join(dir, "a.cs"), filepath.Join(dir, "b.cs")})).To(MatchError(`invalid level 0`))
+		Expect(runDiff([]string{"-level", "31", filepath.Join(dir, "a.cs"), filepath.Join(dir, "b.cs")})).To(MatchError(`invalid level 31`))
+	})
+})
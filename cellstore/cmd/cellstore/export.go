@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bsm/geokit/cellstore"
+	"github.com/golang/geo/s2"
+)
+
+func runExport(args []string) error {
+	var (
+		output string
+		format string
+		values string
+		rect   string
+		cells  string
+		eopts  cellstore.ExportOptions
+	)
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.StringVar(&output, "o", "", "output file (default: STDOUT)")
+	fs.StringVar(&format, "format", "geojson", "output format, geojson, csv or ndjson")
+	fs.StringVar(&values, "values", "hex", "value rendering, hex, base64, utf8 or json")
+	fs.BoolVar(&eopts.Polygons, "polygons", false, "export GeoJSON cell polygons instead of points")
+	fs.StringVar(&rect, "rect", "", "limit to a rectangle, given by its south-west and north-east corners as lat_sw,lng_sw,lat_ne,lng_ne (west > east crosses the antimeridian)")
+	fs.StringVar(&cells, "cells", "", "limit to a union of cells, given as comma-separated cell tokens")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cellstore export [flags] store")
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one store")
+	}
+
+	switch format {
+	case "geojson":
+		eopts.Format = cellstore.ExportGeoJSON
+	case "csv":
+		eopts.Format = cellstore.ExportCSV
+	case "ndjson":
+		eopts.Format = cellstore.ExportNDJSON
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+
+	switch values {
+	case "hex":
+		eopts.Decoder = cellstore.HexValues
+	case "base64":
+		eopts.Decoder = cellstore.Base64Values
+	case "utf8":
+		eopts.Decoder = cellstore.UTF8Values
+	case "json":
+		eopts.Decoder = decodeJSONValue
+	default:
+		return fmt.Errorf("invalid value rendering %q", values)
+	}
+
+	var err error
+	switch {
+	case rect != "" && cells != "":
+		return errors.New("only one of -rect and -cells can be specified")
+	case rect != "":
+		eopts.Region, err = parseRect(rect)
+	case cells != "":
+		eopts.Region, err = parseCellUnion(cells)
+	}
+	if err != nil {
+		return err
+	}
+
+	r, closer, err := openReader(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	if output == "" {
+		return cellstore.Export(os.Stdout, r, &eopts)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := cellstore.Export(f, r, &eopts); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func decodeJSONValue(value []byte) (interface{}, error) {
+	if !json.Valid(value) {
+		return nil, errors.New("value is not valid JSON")
+	}
+	return json.RawMessage(value), nil
+}
+
+func parseRect(s string) (*s2.Rect, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid rectangle %q", s)
+	}
+
+	var deg [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rectangle %q: %w", s, err)
+		}
+		deg[i] = f
+	}
+
+	rect := cellstore.RectFromCorners(s2.LatLngFromDegrees(deg[0], deg[1]), s2.LatLngFromDegrees(deg[2], deg[3]))
+	return &rect, nil
+}
+
+func parseCellUnion(s string) (*s2.CellUnion, error) {
+	var cu s2.CellUnion
+	for _, token := range strings.Split(s, ",") {
+		cellID := s2.CellIDFromToken(strings.TrimSpace(token))
+		if !cellID.IsValid() {
+			return nil, fmt.Errorf("invalid cell token %q", token)
+		}
+		cu = append(cu, cellID)
+	}
+	cu.Normalize()
+	return &cu, nil
+}
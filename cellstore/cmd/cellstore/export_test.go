@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("export", func() {
+	var dir, store string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		input := filepath.Join(dir, "input.csv")
+		store = filepath.Join(dir, "store.cs")
+		Expect(os.WriteFile(input, []byte("51.5,-0.12,London\n48.86,2.35,Paris\n"), 0o644)).To(Succeed())
+		Expect(runBuild([]string{"-o", store, "-level", "20", input})).To(Succeed())
+	})
+
+	It("should export stores", func() {
+		output := filepath.Join(dir, "output.csv")
+		Expect(runExport([]string{"-o", output, "-format", "csv", "-values", "utf8", "-rect", "50,-1,52,1", store})).To(Succeed())
+		Expect(os.ReadFile(output)).To(Equal([]byte(
+			"cell_id,level,lat,lng,value\n" +
+				"487604c7267,20,51.49995910975388,-0.11999724570310176,London\n",
+		)))
+	})
+
+	It("should parse regions", func() {
+		rect, err := parseRect("50,-1,52,1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(51.5, -0.12))).To(BeTrue())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(48.86, 2.35))).To(BeFalse())
+
+		// wide viewports may cross the antimeridian
+		rect, err = parseRect("-10,10,10,-170")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(0, 100))).To(BeTrue())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(0, 180))).To(BeTrue())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(0, -175))).To(BeTrue())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(0, 0))).To(BeFalse())
+		Expect(rect.ContainsLatLng(s2.LatLngFromDegrees(0, -160))).To(BeFalse())
+
+		cu, err := parseCellUnion("487604c, 47e66e1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cu.ContainsCellID(s2.CellIDFromToken("487604c7267"))).To(BeTrue())
+
+		_, err = parseCellUnion("487604c,x")
+		Expect(err).To(MatchError(`invalid cell token "x"`))
+	})
+})
@@ -0,0 +1,88 @@
+// Command cellstore builds and inspects cellstore files.
+//
+// Usage:
+//
+//	cellstore build [flags] [input files...]
+//	cellstore diff [flags] old new
+//	cellstore export [flags] store
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/bsm/geokit/cellstore"
+)
+
+type command struct {
+	Summary string
+	Run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"build":  {Summary: "build a cellstore from CSV or GeoJSON input", Run: runBuild},
+	"diff":   {Summary: "report differences between two cellstores", Run: runDiff},
+	"export": {Summary: "export cellstore entries as GeoJSON, CSV or NDJSON", Run: runExport},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "cellstore: unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err := cmd.Run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "cellstore %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: cellstore <command> [flags] [args...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].Summary)
+	}
+}
+
+func openReader(name string) (*cellstore.Reader, io.Closer, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	r, err := cellstore.NewReader(f, fi.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	return r, f, nil
+}
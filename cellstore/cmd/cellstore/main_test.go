@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+)
+
+func TestSuite(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "geokit/cellstore/cmd/cellstore")
+}
@@ -0,0 +1,61 @@
+package cellstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Containing returns all stored cells which contain cellID, i.e. cellID
+// itself and all of its ancestors, ordered from the largest to the smallest
+// cell. It is typically used to look up coverage areas, which are stored as
+// cells at lower levels, by a leaf cell.
+func (r *Reader) Containing(cellID s2.CellID) (*NearbyRS, error) {
+	return r.ContainingContext(context.Background(), cellID)
+}
+
+// ContainingContext is like Containing but aborts the search with
+// ctx.Err() once the context is cancelled.
+func (r *Reader) ContainingContext(ctx context.Context, cellID s2.CellID) (*NearbyRS, error) {
+	if !cellID.IsValid() {
+		return nil, errInvalidCellID
+	}
+
+	qs := r.newQueryStats("Containing")
+	defer r.observe(qs)
+
+	// look up all ancestors in a single pass, in key order
+	keys := make([]s2.CellID, 0, cellID.Level()+1)
+	for level := 0; level <= cellID.Level(); level++ {
+		keys = append(keys, cellID.Parent(level))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	rs := newNearbyRS()
+	rs.Earth = r.earth
+	if err := r.lookupKeys(ctx, keys, qs, func(cellID s2.CellID, value []byte) error {
+		rs.add(cellID, value, 0)
+		return nil
+	}); err != nil {
+		rs.Release()
+		return nil, err
+	}
+	sort.Slice(rs.Entries, func(i, j int) bool { return rs.Entries[i].Level() < rs.Entries[j].Level() })
+
+	if qs != nil {
+		qs.EntriesReturned = rs.Len()
+	}
+	return rs, nil
+}
+
+// cellDistance returns the distance between a stored cell and a point. Leaf
+// cells are treated as points, for cells at lower levels the distance to
+// the closest point of the cell's area is returned.
+func cellDistance(cellID s2.CellID, p s2.Point) s1.Angle {
+	if cellID.IsLeaf() {
+		return cellID.Point().Distance(p)
+	}
+	return s2.CellFromCellID(cellID).Distance(p).Angle()
+}
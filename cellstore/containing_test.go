@@ -0,0 +1,134 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Reader.Containing", func() {
+	var subject *cellstore.Reader
+
+	london := cellIDFromDegrees(51.5, -0.12)
+	paris := cellIDFromDegrees(48.86, 2.35)
+
+	cellIDs := func(rs *cellstore.NearbyRS) []s2.CellID {
+		res := make([]s2.CellID, 0, rs.Len())
+		for _, ent := range rs.Entries {
+			res = append(res, ent.CellID)
+		}
+		return res
+	}
+
+	BeforeEach(func() {
+		subject = seedStore(map[s2.CellID]string{
+			london.Parent(5):  "region",
+			london.Parent(10): "city",
+			london.Parent(12): "district",
+			london:            "London",
+			paris.Parent(10):  "Paris",
+			paris:             "Paris",
+		})
+	})
+
+	It("should find containing cells", func() {
+		rs, err := subject.Containing(london)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+
+		Expect(cellIDs(rs)).To(Equal([]s2.CellID{
+			london.Parent(5),
+			london.Parent(10),
+			london.Parent(12),
+			london,
+		}))
+		Expect(string(rs.Entries[1].Value)).To(Equal("city"))
+
+		rs, err = subject.Containing(london.Next())
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(cellIDs(rs)).To(Equal([]s2.CellID{
+			london.Parent(5),
+			london.Parent(10),
+			london.Parent(12),
+		}))
+
+		rs, err = subject.Containing(london.Parent(11))
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(cellIDs(rs)).To(Equal([]s2.CellID{
+			london.Parent(5),
+			london.Parent(10),
+		}))
+
+		rs, err = subject.Containing(cellIDFromDegrees(40.7, -74.0))
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs.Len()).To(BeZero())
+	})
+
+	It("should find containing cells across blocks with filters", func() {
+		entries := map[s2.CellID]string{
+			london.Parent(5):  "region",
+			london.Parent(10): "city",
+			london.Parent(12): "district",
+			london:            "London",
+		}
+		for i := 0; i < 200; i++ {
+			entries[london.Parent(12).ChildBeginAtLevel(20).Advance(int64(i))] = "filler"
+		}
+
+		b := cellstore.NewBuilder(nil)
+		defer b.Close()
+		for cellID, value := range entries {
+			Expect(b.Append(cellID, []byte(value))).To(Succeed())
+		}
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{
+			WriterOptions: sntable.WriterOptions{BlockSize: 256, BlockRestartInterval: 4},
+			FilterFPRate:  0.01,
+		})
+		Expect(b.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.NumBlocks()).To(BeNumerically(">", 4))
+
+		rs, err := r.Containing(london)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(cellIDs(rs)).To(Equal([]s2.CellID{
+			london.Parent(5),
+			london.Parent(10),
+			london.Parent(12),
+			london,
+		}))
+	})
+
+	It("should reject invalid cell IDs", func() {
+		_, err := subject.Containing(1317624576600000002)
+		Expect(err).To(MatchError(`cellstore: invalid cell ID`))
+	})
+
+	It("should measure distances to areas", func() {
+		rs, err := subject.Nearby(london.Next(), 3)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+
+		Expect(rs.Entries[0].Distance).To(BeZero())
+		Expect(rs.Entries[1].Distance).To(BeZero())
+		Expect(rs.Entries[2].Distance).To(BeZero())
+		Expect(cellIDs(rs)).To(ConsistOf(london.Parent(5), london.Parent(10), london.Parent(12)))
+
+		rs, err = subject.NearbyWithOptions(paris.Parent(10).ChildBegin(), 10, &cellstore.NearbyOptions{MaxDistance: 1})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(cellIDs(rs)).To(ContainElement(paris.Parent(10)))
+	})
+})
@@ -0,0 +1,66 @@
+package cellstore_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Context", func() {
+	var subject *cellstore.Reader
+	var cancelled context.Context
+
+	BeforeEach(func() {
+		subject = seedInMem(1000)
+
+		var cancel context.CancelFunc
+		cancelled, cancel = context.WithCancel(context.Background())
+		cancel()
+	})
+
+	It("should abort nearby searches", func() {
+		_, err := subject.NearbyContext(cancelled, seedCellID+800, 10, nil)
+		Expect(err).To(MatchError(context.Canceled))
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err = subject.NearbyContext(ctx, seedCellID+800, 10, nil)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		rs, err := subject.NearbyContext(context.Background(), seedCellID+800, 10, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(10))
+		rs.Release()
+	})
+
+	It("should abort rectangle searches", func() {
+		_, err := subject.WithinRectContext(cancelled, s2.FullRect(), 0)
+		Expect(err).To(MatchError(context.Canceled))
+
+		rs, err := subject.WithinRectContext(context.Background(), s2.FullRect(), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(1000))
+		rs.Release()
+	})
+
+	It("should abort aggregations", func() {
+		_, err := subject.AggregateContext(cancelled, nil, 10, nil)
+		Expect(err).To(MatchError(context.Canceled))
+
+		_, err = subject.AggregateRangeContext(cancelled, seedCellID, seedCellID+8000, 10, nil)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+
+	It("should allow to release iterators multiple times", func() {
+		iter, err := subject.FindSection(seedCellID)
+		Expect(err).NotTo(HaveOccurred())
+		iter.Release()
+		iter.Release()
+		Expect(iter.Err()).To(MatchError(`cellstore: already released`))
+	})
+})
@@ -0,0 +1,159 @@
+package cellstore
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/golang/geo/s2"
+)
+
+// DiffKind is the kind of a difference between two stores.
+type DiffKind uint8
+
+// Kinds of differences.
+const (
+	DiffAdded DiffKind = iota + 1
+	DiffRemoved
+	DiffChanged
+)
+
+// String returns the name of the kind.
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// DiffFunc is called for each difference, in cell ID order. Old is nil for
+// added, new is nil for removed entries. Values must not be retained.
+type DiffFunc func(kind DiffKind, cellID s2.CellID, old, new []byte) error
+
+// DiffOptions define Diff specific options.
+type DiffOptions struct {
+	// Level is the level of the parent cells which differences
+	// are summarised by, between 1 and 30.
+	// Default: 8
+	Level int
+
+	// OnDiff is an optional callback for each difference.
+	OnDiff DiffFunc
+}
+
+func (o *DiffOptions) norm() *DiffOptions {
+	var oo DiffOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.Level <= 0 || oo.Level > s2.MaxLevel {
+		oo.Level = 8
+	}
+	return &oo
+}
+
+// DiffCounts contains the number of differences.
+type DiffCounts struct {
+	Added, Removed, Changed int
+}
+
+// Total returns the total number of differences.
+func (c DiffCounts) Total() int {
+	return c.Added + c.Removed + c.Changed
+}
+
+func (c *DiffCounts) inc(kind DiffKind) {
+	switch kind {
+	case DiffAdded:
+		c.Added++
+	case DiffRemoved:
+		c.Removed++
+	case DiffChanged:
+		c.Changed++
+	}
+}
+
+// DiffSummary summarises the differences between two stores.
+type DiffSummary struct {
+	DiffCounts
+
+	// Unchanged is the number of equal entries.
+	Unchanged int
+
+	// Cells contains the differences by parent cell.
+	Cells map[s2.CellID]DiffCounts
+}
+
+// Diff walks both stores in cell ID order and reports entries which were
+// added to, removed from or changed in b, compared to a.
+func Diff(a, b *Reader, o *DiffOptions) (*DiffSummary, error) {
+	return DiffContext(context.Background(), a, b, o)
+}
+
+// DiffContext is like Diff but aborts with ctx.Err() once
+// the context is cancelled.
+func DiffContext(ctx context.Context, a, b *Reader, o *DiffOptions) (*DiffSummary, error) {
+	o = o.norm()
+
+	ai, bi := a.All(), b.All()
+	defer ai.Release()
+	defer bi.Release()
+
+	sum := &DiffSummary{Cells: make(map[s2.CellID]DiffCounts)}
+	report := func(kind DiffKind, cellID s2.CellID, old, new []byte) error {
+		parent := cellID
+		if parent.Level() > o.Level {
+			parent = parent.Parent(o.Level)
+		}
+		cnt := sum.Cells[parent]
+		cnt.inc(kind)
+		sum.Cells[parent] = cnt
+		sum.inc(kind)
+
+		if o.OnDiff != nil {
+			return o.OnDiff(kind, cellID, old, new)
+		}
+		return nil
+	}
+
+	aok, bok := ai.Next(), bi.Next()
+	for n := 0; aok || bok; n++ {
+		if n%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case !bok || (aok && ai.CellID() < bi.CellID()):
+			if err := report(DiffRemoved, ai.CellID(), ai.Value(), nil); err != nil {
+				return nil, err
+			}
+			aok = ai.Next()
+		case !aok || bi.CellID() < ai.CellID():
+			if err := report(DiffAdded, bi.CellID(), nil, bi.Value()); err != nil {
+				return nil, err
+			}
+			bok = bi.Next()
+		default:
+			if bytes.Equal(ai.Value(), bi.Value()) {
+				sum.Unchanged++
+			} else if err := report(DiffChanged, ai.CellID(), ai.Value(), bi.Value()); err != nil {
+				return nil, err
+			}
+			aok, bok = ai.Next(), bi.Next()
+		}
+	}
+
+	if err := ai.Err(); err != nil {
+		return nil, err
+	}
+	if err := bi.Err(); err != nil {
+		return nil, err
+	}
+	return sum, nil
+}
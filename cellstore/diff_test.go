@@ -0,0 +1,73 @@
+package cellstore_test
+
+import (
+	"errors"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Diff", func() {
+	london := cellIDFromDegrees(51.5, -0.12)
+	oxford := cellIDFromDegrees(51.75, -1.25)
+	paris := cellIDFromDegrees(48.86, 2.35)
+	berlin := cellIDFromDegrees(52.52, 13.4)
+
+	a := func() *cellstore.Reader {
+		return seedStore(map[s2.CellID]string{london: "london", oxford: "oxford", paris: "paris"})
+	}
+	b := func() *cellstore.Reader {
+		return seedStore(map[s2.CellID]string{london: "london", oxford: "oxford v2", berlin: "berlin"})
+	}
+
+	It("should report differences", func() {
+		type diff struct {
+			Kind     cellstore.DiffKind
+			CellID   s2.CellID
+			Old, New string
+		}
+
+		var diffs []diff
+		sum, err := cellstore.Diff(a(), b(), &cellstore.DiffOptions{
+			Level: 4,
+			OnDiff: func(kind cellstore.DiffKind, cellID s2.CellID, old, new []byte) error {
+				diffs = append(diffs, diff{Kind: kind, CellID: cellID, Old: string(old), New: string(new)})
+				return nil
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffs).To(Equal([]diff{
+			{Kind: cellstore.DiffAdded, CellID: berlin, New: "berlin"},
+			{Kind: cellstore.DiffRemoved, CellID: paris, Old: "paris"},
+			{Kind: cellstore.DiffChanged, CellID: oxford, Old: "oxford", New: "oxford v2"},
+		}))
+
+		Expect(sum.DiffCounts).To(Equal(cellstore.DiffCounts{Added: 1, Removed: 1, Changed: 1}))
+		Expect(sum.Total()).To(Equal(3))
+		Expect(sum.Unchanged).To(Equal(1))
+		Expect(sum.Cells).To(Equal(map[s2.CellID]cellstore.DiffCounts{
+			oxford.Parent(4): {Changed: 1},
+			paris.Parent(4):  {Removed: 1},
+			berlin.Parent(4): {Added: 1},
+		}))
+		Expect(cellstore.DiffChanged.String()).To(Equal("changed"))
+	})
+
+	It("should report no differences for equal stores", func() {
+		sum, err := cellstore.Diff(a(), a(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sum.Total()).To(Equal(0))
+		Expect(sum.Unchanged).To(Equal(3))
+		Expect(sum.Cells).To(BeEmpty())
+	})
+
+	It("should abort on callback errors", func() {
+		errStop := errors.New("stop")
+		_, err := cellstore.Diff(a(), b(), &cellstore.DiffOptions{
+			OnDiff: func(cellstore.DiffKind, s2.CellID, []byte, []byte) error { return errStop },
+		})
+		Expect(err).To(MatchError(errStop))
+	})
+})
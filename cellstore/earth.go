@@ -0,0 +1,70 @@
+package cellstore
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Earth is a spherical Earth model which converts angular distances
+// to metric distances and back.
+type Earth struct {
+	// Radius is the radius of the sphere in metres.
+	Radius float64
+}
+
+// meanEarthRadius is the mean Earth radius in metres, as defined by the IUGG.
+const meanEarthRadius = 6371008.8
+
+// DefaultEarth is the model of readers without a custom ReaderOptions.Earth,
+// using the mean Earth radius as defined by the IUGG. Changes only apply to
+// readers which are opened afterwards.
+var DefaultEarth = Earth{Radius: meanEarthRadius}
+
+// or returns e, or fallback if e has no radius.
+func (e Earth) or(fallback Earth) Earth {
+	if e.Radius > 0 {
+		return e
+	}
+	return fallback
+}
+
+// Meters converts an angle to metres.
+func (e Earth) Meters(a s1.Angle) float64 {
+	return a.Radians() * e.Radius
+}
+
+// Kilometers converts an angle to kilometres.
+func (e Earth) Kilometers(a s1.Angle) float64 {
+	return e.Meters(a) / 1000
+}
+
+// Angle converts a distance in metres to an angle.
+func (e Earth) Angle(meters float64) s1.Angle {
+	return s1.Angle(meters / e.Radius)
+}
+
+// Cap returns a cap around center with a radius in metres.
+func (e Earth) Cap(center s2.Point, meters float64) s2.Cap {
+	return s2.CapFromCenterAngle(center, e.Angle(meters))
+}
+
+// initialBearing returns the initial bearing (forward azimuth) of the great
+// circle path between a and b, measured clockwise from north in [0, 2π).
+func initialBearing(a, b s2.LatLng) s1.Angle {
+	dlng := (b.Lng - a.Lng).Radians()
+	lat1, lat2 := a.Lat.Radians(), b.Lat.Radians()
+
+	y := math.Sin(dlng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dlng)
+	if x == 0 && y == 0 {
+		return 0
+	}
+
+	rad := math.Atan2(y, x)
+	if rad < 0 {
+		rad += 2 * math.Pi
+	}
+	return s1.Angle(rad)
+}
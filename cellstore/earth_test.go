@@ -0,0 +1,69 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Earth", func() {
+	subject := cellstore.DefaultEarth
+
+	It("should convert distances", func() {
+		Expect(subject.Meters(s1.Degree)).To(BeNumerically("~", 111195.08, 0.01))
+		Expect(subject.Kilometers(s1.Degree)).To(BeNumerically("~", 111.195, 0.001))
+		Expect(subject.Angle(111195.08).Degrees()).To(BeNumerically("~", 1.0, 1e-6))
+
+		wgs84 := cellstore.Earth{Radius: 6378137}
+		Expect(wgs84.Meters(s1.Degree)).To(BeNumerically("~", 111319.49, 0.01))
+	})
+
+	It("should create caps", func() {
+		london := s2.PointFromLatLng(s2.LatLngFromDegrees(51.5, -0.12))
+		paris := s2.PointFromLatLng(s2.LatLngFromDegrees(48.86, 2.35))
+
+		Expect(subject.Cap(london, 350e3).ContainsPoint(paris)).To(BeTrue())
+		Expect(subject.Cap(london, 340e3).ContainsPoint(paris)).To(BeFalse())
+	})
+
+	It("should be configurable per reader and query", func() {
+		london := cellIDFromDegrees(51.5, -0.12)
+		paris := cellIDFromDegrees(48.86, 2.35)
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, nil)
+		Expect(w.Append(uint64(paris), []byte("Paris"))).To(Succeed())
+		Expect(w.Append(uint64(london), []byte("London"))).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		// on a sphere twice the size, Paris is ~684km from London
+		big := cellstore.Earth{Radius: 2 * subject.Radius}
+		r, err := cellstore.NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &cellstore.ReaderOptions{Earth: big})
+		Expect(err).NotTo(HaveOccurred())
+
+		rs, err := r.NearbyWithOptions(london, 10, &cellstore.NearbyOptions{MaxDistance: 400e3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(1))
+		Expect(rs.Earth).To(Equal(big))
+		rs.Release()
+
+		rs, err = r.NearbyWithOptions(london, 10, &cellstore.NearbyOptions{MaxDistance: 400e3, Earth: subject})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(2))
+		Expect(rs.Earth).To(Equal(subject))
+		Expect(rs.Earth.Meters(rs.Entries[1].Distance)).To(BeNumerically("~", 342165, 1))
+		Expect(rs.Entries[1].Meters()).To(BeNumerically("~", 342165, 1))
+		rs.Release()
+
+		rs, err = r.Nearby(london, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(2))
+		Expect(rs.Entries[1].Meters()).To(BeNumerically("~", 684330, 1))
+		Expect(rs.Entries[1].Kilometers()).To(BeNumerically("~", 684.33, 0.01))
+		rs.Release()
+	})
+})
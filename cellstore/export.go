@@ -0,0 +1,276 @@
+package cellstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/golang/geo/s2"
+)
+
+// ExportFormat is the output format of an export.
+type ExportFormat uint8
+
+// Supported export formats.
+const (
+	ExportGeoJSON ExportFormat = iota
+	ExportCSV
+	ExportNDJSON
+)
+
+// ValueDecoder decodes stored values for an export. Results are
+// JSON-encoded, strings are written as-is in CSV exports.
+type ValueDecoder func(value []byte) (interface{}, error)
+
+// HexValues renders values as hex strings.
+func HexValues(value []byte) (interface{}, error) {
+	return hex.EncodeToString(value), nil
+}
+
+// Base64Values renders values as standard base64 strings.
+func Base64Values(value []byte) (interface{}, error) {
+	return base64.StdEncoding.EncodeToString(value), nil
+}
+
+// UTF8Values renders values as UTF-8 strings.
+func UTF8Values(value []byte) (interface{}, error) {
+	if !utf8.Valid(value) {
+		return nil, errInvalidUTF8
+	}
+	return string(value), nil
+}
+
+// ExportOptions define export specific options.
+type ExportOptions struct {
+	// Format is the output format.
+	// Default: ExportGeoJSON
+	Format ExportFormat
+
+	// Region limits the export to entries within the region.
+	// Default: all entries are exported.
+	Region s2.Region
+
+	// Polygons exports GeoJSON features with cell polygons
+	// instead of points at the cell centre.
+	Polygons bool
+
+	// Decoder renders values.
+	// Default: HexValues
+	Decoder ValueDecoder
+}
+
+func (o *ExportOptions) norm() *ExportOptions {
+	var oo ExportOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.Decoder == nil {
+		oo.Decoder = HexValues
+	}
+	return &oo
+}
+
+// Export streams entries from r to w.
+func Export(w io.Writer, r *Reader, o *ExportOptions) error {
+	o = o.norm()
+
+	bw := bufio.NewWriter(w)
+	var x exporter
+	switch o.Format {
+	case ExportGeoJSON:
+		x = &geoJSONExporter{w: bw, polygons: o.Polygons}
+	case ExportCSV:
+		x = &csvExporter{w: csv.NewWriter(bw)}
+	case ExportNDJSON:
+		x = &ndJSONExporter{enc: json.NewEncoder(bw)}
+	default:
+		return errInvalidExportFormat
+	}
+
+	if err := x.Begin(); err != nil {
+		return err
+	}
+
+	qs := r.newQueryStats("Export")
+	defer r.observe(qs)
+
+	fn := func(cellID s2.CellID, value []byte) error {
+		if qs != nil {
+			qs.EntriesReturned++
+		}
+
+		v, err := o.Decoder(value)
+		if err != nil {
+			return err
+		}
+		return x.Write(cellID, v)
+	}
+
+	var err error
+	if o.Region != nil {
+		err = r.scanRegion(context.Background(), o.Region, 0, qs, fn)
+	} else {
+		err = r.scanRange(context.Background(), 0, ^s2.CellID(0), qs, fn)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := x.End(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// --------------------------------------------------------------------
+
+type exporter interface {
+	Begin() error
+	Write(cellID s2.CellID, value interface{}) error
+	End() error
+}
+
+type exportEntry struct {
+	CellID string      `json:"cell_id"`
+	Level  int         `json:"level"`
+	Lat    float64     `json:"lat"`
+	Lng    float64     `json:"lng"`
+	Value  interface{} `json:"value"`
+}
+
+func newExportEntry(cellID s2.CellID, value interface{}) *exportEntry {
+	ll := cellID.LatLng()
+	return &exportEntry{
+		CellID: cellID.ToToken(),
+		Level:  cellID.Level(),
+		Lat:    ll.Lat.Degrees(),
+		Lng:    ll.Lng.Degrees(),
+		Value:  value,
+	}
+}
+
+type geoJSONExporter struct {
+	w        *bufio.Writer
+	polygons bool
+	n        int
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties *exportEntry    `json:"properties"`
+}
+
+func (x *geoJSONExporter) Begin() error {
+	_, err := x.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (x *geoJSONExporter) Write(cellID s2.CellID, value interface{}) error {
+	ent := newExportEntry(cellID, value)
+	feat := geoJSONFeature{
+		Type:       "Feature",
+		ID:         ent.CellID,
+		Properties: ent,
+	}
+
+	if x.polygons {
+		cell := s2.CellFromCellID(cellID)
+		ring := make([][2]float64, 5)
+		for i := 0; i < 4; i++ {
+			ll := s2.LatLngFromPoint(cell.Vertex(i))
+			ring[i] = [2]float64{ll.Lng.Degrees(), ll.Lat.Degrees()}
+		}
+		ring[4] = ring[0]
+		feat.Geometry = geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}
+	} else {
+		feat.Geometry = geoJSONGeometry{Type: "Point", Coordinates: [2]float64{ent.Lng, ent.Lat}}
+	}
+
+	data, err := json.Marshal(feat)
+	if err != nil {
+		return err
+	}
+
+	if x.n != 0 {
+		if err := x.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	x.n++
+
+	if err := x.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	_, err = x.w.Write(data)
+	return err
+}
+
+func (x *geoJSONExporter) End() error {
+	_, err := x.w.WriteString("\n]}\n")
+	return err
+}
+
+type csvExporter struct {
+	w   *csv.Writer
+	rec []string
+}
+
+func (x *csvExporter) Begin() error {
+	return x.w.Write([]string{"cell_id", "level", "lat", "lng", "value"})
+}
+
+func (x *csvExporter) Write(cellID s2.CellID, value interface{}) error {
+	ent := newExportEntry(cellID, value)
+
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		str = string(data)
+	}
+
+	x.rec = append(x.rec[:0],
+		ent.CellID,
+		strconv.Itoa(ent.Level),
+		strconv.FormatFloat(ent.Lat, 'f', -1, 64),
+		strconv.FormatFloat(ent.Lng, 'f', -1, 64),
+		str,
+	)
+	return x.w.Write(x.rec)
+}
+
+func (x *csvExporter) End() error {
+	x.w.Flush()
+	return x.w.Error()
+}
+
+type ndJSONExporter struct {
+	enc *json.Encoder
+}
+
+func (x *ndJSONExporter) Begin() error { return nil }
+func (x *ndJSONExporter) End() error   { return nil }
+
+func (x *ndJSONExporter) Write(cellID s2.CellID, value interface{}) error {
+	return x.enc.Encode(newExportEntry(cellID, value))
+}
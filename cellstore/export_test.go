@@ -0,0 +1,101 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Export", func() {
+	var subject *cellstore.Reader
+
+	london := cellIDFromDegrees(51.5, -0.12).Parent(20)
+	paris := cellIDFromDegrees(48.86, 2.35).Parent(20)
+
+	export := func(o *cellstore.ExportOptions) (string, error) {
+		buf := new(bytes.Buffer)
+		err := cellstore.Export(buf, subject, o)
+		return buf.String(), err
+	}
+
+	BeforeEach(func() {
+		subject = seedStore(map[s2.CellID]string{
+			london: "London",
+			paris:  "Paris",
+		})
+	})
+
+	It("should export NDJSON", func() {
+		Expect(export(&cellstore.ExportOptions{Format: cellstore.ExportNDJSON})).To(Equal(
+			`{"cell_id":"47e66e1ea23","level":20,"lat":48.860007396742354,"lng":2.3499971229358123,"value":"5061726973"}` + "\n" +
+				`{"cell_id":"487604c7267","level":20,"lat":51.49995910975388,"lng":-0.11999724570310176,"value":"4c6f6e646f6e"}` + "\n",
+		))
+	})
+
+	It("should export CSV", func() {
+		Expect(export(&cellstore.ExportOptions{Format: cellstore.ExportCSV, Decoder: cellstore.UTF8Values})).To(Equal(
+			"cell_id,level,lat,lng,value\n" +
+				"47e66e1ea23,20,48.860007396742354,2.3499971229358123,Paris\n" +
+				"487604c7267,20,51.49995910975388,-0.11999724570310176,London\n",
+		))
+	})
+
+	It("should export GeoJSON", func() {
+		var fc struct {
+			Type     string
+			Features []struct {
+				ID       string
+				Geometry struct {
+					Type        string
+					Coordinates json.RawMessage
+				}
+				Properties map[string]interface{}
+			}
+		}
+
+		out, err := export(&cellstore.ExportOptions{Decoder: cellstore.Base64Values})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal([]byte(out), &fc)).To(Succeed())
+		Expect(fc.Type).To(Equal("FeatureCollection"))
+		Expect(fc.Features).To(HaveLen(2))
+		Expect(fc.Features[0].ID).To(Equal("47e66e1ea23"))
+		Expect(fc.Features[0].Geometry.Type).To(Equal("Point"))
+		Expect(fc.Features[0].Geometry.Coordinates).To(MatchJSON(`[2.3499971229358123,48.860007396742354]`))
+		Expect(fc.Features[0].Properties).To(HaveKeyWithValue("value", "UGFyaXM="))
+
+		out, err = export(&cellstore.ExportOptions{Polygons: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal([]byte(out), &fc)).To(Succeed())
+		Expect(fc.Features).To(HaveLen(2))
+		Expect(fc.Features[1].Geometry.Type).To(Equal("Polygon"))
+	})
+
+	It("should export within regions", func() {
+		region := s2.CapFromCenterAngle(s2.PointFromLatLng(s2.LatLngFromDegrees(51.5, 0)), s1.Degree)
+		Expect(export(&cellstore.ExportOptions{
+			Format:  cellstore.ExportCSV,
+			Region:  region,
+			Decoder: cellstore.UTF8Values,
+		})).To(Equal(
+			"cell_id,level,lat,lng,value\n" +
+				"487604c7267,20,51.49995910975388,-0.11999724570310176,London\n",
+		))
+	})
+
+	It("should support custom decoders", func() {
+		Expect(export(&cellstore.ExportOptions{
+			Format: cellstore.ExportNDJSON,
+			Region: s2.CellFromCellID(paris.Parent(10)),
+			Decoder: func(v []byte) (interface{}, error) {
+				return map[string]int{"len": len(v)}, nil
+			},
+		})).To(Equal(
+			`{"cell_id":"47e66e1ea23","level":20,"lat":48.860007396742354,"lng":2.3499971229358123,"value":{"len":5}}` + "\n",
+		))
+	})
+})
@@ -1,5 +1,10 @@
 package cellstore
 
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
 // BPos returns the block position.
 func (i *SectionIterator) BPos() int { return i.b.Pos() }
 
@@ -7,4 +12,14 @@ func (i *SectionIterator) BPos() int { return i.b.Pos() }
 func (i *SectionIterator) SPos() int { return i.s.Pos() }
 
 // Sort sorts entries by distance.
-func (n *NearbyRS) Sort() { n.sort() }
+func (n *NearbyRS) Sort() {
+	for i := len(n.Entries)/2 - 1; i > -1; i-- {
+		n.down(i, len(n.Entries))
+	}
+	n.sort()
+}
+
+// Push pushes an entry to a heap of limited size.
+func (n *NearbyRS) Push(cellID s2.CellID, value []byte, distance s1.Angle, limit int) bool {
+	return n.push(&NearbyEntry{CellID: cellID, Value: value, Distance: distance}, limit)
+}
@@ -0,0 +1,70 @@
+package cellstore
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/golang/geo/s2"
+)
+
+// Extract copies all entries of r within region to w. Metadata of r is
+// carried over and the covering of region is recorded, see Reader.Region.
+// Layers are not copied, they can be extracted separately via Reader.Layer
+// and Writer.Layer. Extract does not close w.
+func Extract(r *Reader, region s2.Region, w *Writer) error {
+	return ExtractContext(context.Background(), r, region, w)
+}
+
+// ExtractContext is like Extract but aborts with ctx.Err() once
+// the context is cancelled.
+func ExtractContext(ctx context.Context, r *Reader, region s2.Region, w *Writer) error {
+	cover := coverRegion(region)
+	if prev, err := r.Region(); err != nil {
+		return err
+	} else if prev != nil {
+		cover = s2.CellUnionFromIntersection(cover, prev)
+	}
+
+	qs := r.newQueryStats("Extract")
+	defer r.observe(qs)
+
+	if err := r.scanRegion(ctx, region, 0, qs, func(cellID s2.CellID, value []byte) error {
+		if qs != nil {
+			qs.EntriesReturned++
+		}
+		return w.Append(uint64(cellID), value)
+	}); err != nil {
+		return err
+	}
+
+	// filters, hashes and layers are specific to the source file
+	for k, v := range r.meta {
+		switch k {
+		case metaFilters, metaContentHash, metaLayers, metaTableSize:
+		default:
+			w.setMeta(k, v)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := cover.Encode(&buf); err != nil {
+		return err
+	}
+	w.setMeta(metaRegion, buf.Bytes())
+	return nil
+}
+
+// Region returns the covering of the region the store was extracted from.
+// It returns nil for stores which were not created by Extract.
+func (r *Reader) Region() (s2.CellUnion, error) {
+	p, ok := r.meta[metaRegion]
+	if !ok {
+		return nil, nil
+	}
+
+	var cu s2.CellUnion
+	if err := cu.Decode(bytes.NewReader(p)); err != nil {
+		return nil, errBadMeta
+	}
+	return cu, nil
+}
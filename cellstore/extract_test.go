@@ -0,0 +1,92 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Extract", func() {
+	var subject *cellstore.Reader
+
+	center := s2.PointFromLatLng(s2.LatLngFromDegrees(51.5, -0.12))
+	london := cellstore.DefaultEarth.Cap(center, 50_000)
+	westminster := cellstore.DefaultEarth.Cap(center, 1_000)
+
+	extract := func(r *cellstore.Reader, region s2.Region, o *cellstore.WriterOptions) *cellstore.Reader {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, o)
+		Expect(cellstore.Extract(r, region, w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		x, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		return x
+	}
+
+	collect := func(r *cellstore.Reader) []string {
+		var values []string
+		iter := r.All()
+		defer iter.Release()
+		for iter.Next() {
+			values = append(values, string(iter.Value()))
+		}
+		Expect(iter.Err()).NotTo(HaveOccurred())
+		return values
+	}
+
+	BeforeEach(func() {
+		subject = seedStore(map[s2.CellID]string{
+			cellIDFromDegrees(51.5, -0.12):  "westminster",
+			cellIDFromDegrees(51.52, -0.1):  "clerkenwell",
+			cellIDFromDegrees(51.75, -1.25): "oxford",
+			cellIDFromDegrees(48.86, 2.35):  "paris",
+		})
+	})
+
+	It("should extract entries within a region", func() {
+		x := extract(subject, london, nil)
+		Expect(collect(x)).To(ConsistOf("westminster", "clerkenwell"))
+
+		cu, err := x.Region()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cu.ContainsPoint(london.Center())).To(BeTrue())
+		Expect(cu.ContainsPoint(s2.PointFromLatLng(s2.LatLngFromDegrees(48.86, 2.35)))).To(BeFalse())
+
+		cu, err = subject.Region()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cu).To(BeNil())
+	})
+
+	It("should narrow the recorded region", func() {
+		x := extract(extract(subject, london, nil), westminster, nil)
+		Expect(collect(x)).To(ConsistOf("westminster"))
+
+		cu, err := x.Region()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cu.ContainsPoint(westminster.Center())).To(BeTrue())
+		Expect(cu.ContainsCellID(cellIDFromDegrees(51.52, -0.1))).To(BeFalse())
+	})
+
+	It("should not carry over content hashes", func() {
+		x := extract(subject, london, &cellstore.WriterOptions{ContentHash: true})
+		hash := x.ContentHash()
+		Expect(hash).To(HaveLen(32))
+
+		x = extract(x, london, nil)
+		Expect(x.ContentHash()).To(BeNil())
+		x = extract(x, london, &cellstore.WriterOptions{ContentHash: true})
+		Expect(x.ContentHash()).To(Equal(hash))
+	})
+
+	It("should rebuild filters", func() {
+		x := extract(subject, london, &cellstore.WriterOptions{FilterFPRate: 0.01})
+		Expect(x.Get(uint64(cellIDFromDegrees(51.5, -0.12)))).To(Equal([]byte("westminster")))
+
+		x = extract(x, london, nil)
+		Expect(x.Get(uint64(cellIDFromDegrees(51.5, -0.12)))).To(Equal([]byte("westminster")))
+	})
+})
@@ -0,0 +1,95 @@
+package cellstore
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// bloomFilter is a bloom filter for uint64 keys. The last
+// byte contains the number of hash functions.
+type bloomFilter []byte
+
+// newBloomFilter creates a filter for keys with a false-positive rate.
+func newBloomFilter(keys []uint64, fpRate float64) bloomFilter {
+	n := float64(len(keys))
+	if n < 1 {
+		n = 1
+	}
+
+	bits := int(math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if bits < 64 {
+		bits = 64
+	}
+	k := int(math.Round(float64(bits) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	} else if k > 30 {
+		k = 30
+	}
+
+	f := make(bloomFilter, (bits+7)/8+1)
+	f[len(f)-1] = byte(k)
+	for _, key := range keys {
+		f.add(key)
+	}
+	return f
+}
+
+func (f bloomFilter) add(key uint64) {
+	nbits := uint32(len(f)-1) * 8
+	h1, h2 := bloomHash(key)
+	for i := byte(0); i < f[len(f)-1]; i++ {
+		pos := (h1 + uint32(i)*h2) % nbits
+		f[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// MayContain returns false if the key is definitely not in the set.
+func (f bloomFilter) MayContain(key uint64) bool {
+	if len(f) < 2 {
+		return true
+	}
+
+	nbits := uint32(len(f)-1) * 8
+	h1, h2 := bloomHash(key)
+	for i := byte(0); i < f[len(f)-1]; i++ {
+		pos := (h1 + uint32(i)*h2) % nbits
+		if f[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHash derives two hashes from a key, using the splitmix64 finalizer.
+func bloomHash(key uint64) (uint32, uint32) {
+	key ^= key >> 30
+	key *= 0xbf58476d1ce4e5b9
+	key ^= key >> 27
+	key *= 0x94d049bb133111eb
+	key ^= key >> 31
+	return uint32(key), uint32(key>>32) | 1
+}
+
+// appendFilter appends a length-prefixed filter to the filter section.
+func appendFilter(dst []byte, f bloomFilter) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(f)))
+	return append(dst, f...)
+}
+
+// parseFilters parses the filter section.
+func parseFilters(p []byte, numBlocks int) ([]bloomFilter, error) {
+	filters := make([]bloomFilter, 0, numBlocks)
+	for len(p) != 0 {
+		f, rest, err := readBytes(p)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, bloomFilter(f))
+		p = rest
+	}
+	if len(filters) != numBlocks {
+		return nil, errBadMeta
+	}
+	return filters, nil
+}
@@ -0,0 +1,202 @@
+package cellstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// Cellstore files are plain sntable files, optionally followed by a
+// metadata section and a trailer:
+//
+//	[sntable][metadata][table size: uint64 LE][magic]
+//
+// Files with named layers use a separate magic, which older readers don't
+// recognise, and store the table size in the metadata instead:
+//
+//	[sntable][layers][metadata][metadata offset: uint64 LE][layered magic]
+//
+// Each layer is a cellstore file itself.
+var (
+	fileMagic    = []byte{99, 115, 14, 17, 90, 79, 157, 1}
+	layeredMagic = []byte{99, 115, 14, 17, 90, 79, 157, 2}
+)
+
+// metadata keys
+const (
+	metaFilters     = "filters"
+	metaRegion      = "region"
+	metaContentHash = "content_hash"
+	metaLayers      = "layers"
+	metaTableSize   = "table_size"
+)
+
+// meta is the metadata section of a file.
+type meta map[string][]byte
+
+// AppendTo encodes the metadata, sorted by key.
+func (m meta) AppendTo(dst []byte) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	dst = binary.AppendUvarint(dst, uint64(len(keys)))
+	for _, k := range keys {
+		dst = binary.AppendUvarint(dst, uint64(len(k)))
+		dst = append(dst, k...)
+		dst = binary.AppendUvarint(dst, uint64(len(m[k])))
+		dst = append(dst, m[k]...)
+	}
+	return dst
+}
+
+func parseMeta(p []byte) (meta, error) {
+	num, n := binary.Uvarint(p)
+	if n < 1 || num > uint64(len(p)) {
+		return nil, errBadMeta
+	}
+	p = p[n:]
+
+	m := make(meta, int(num))
+	for i := uint64(0); i < num; i++ {
+		k, rest, err := readBytes(p)
+		if err != nil {
+			return nil, err
+		}
+		v, rest, err := readBytes(rest)
+		if err != nil {
+			return nil, err
+		}
+		m[string(k)] = v
+		p = rest
+	}
+	return m, nil
+}
+
+// readBytes reads a length-prefixed byte slice from p.
+func readBytes(p []byte) ([]byte, []byte, error) {
+	sz, n := binary.Uvarint(p)
+	if n < 1 || sz > uint64(len(p)-n) {
+		return nil, nil, errBadMeta
+	}
+	return p[n : n+int(sz)], p[n+int(sz):], nil
+}
+
+// readTrailer reads the trailer and returns the size of the embedded table
+// along with the metadata. Plain sntable files have no metadata.
+func readTrailer(r io.ReaderAt, size int64) (int64, meta, error) {
+	if size < 32 {
+		return size, nil, nil
+	}
+
+	trailer := make([]byte, 16)
+	if _, err := r.ReadAt(trailer, size-16); err != nil {
+		return 0, nil, err
+	}
+	layered := bytes.Equal(trailer[8:], layeredMagic)
+	if !layered && !bytes.Equal(trailer[8:], fileMagic) {
+		return size, nil, nil
+	}
+
+	metaOffset := int64(binary.LittleEndian.Uint64(trailer))
+	if metaOffset < 16 || metaOffset > size-16 {
+		return 0, nil, errBadMeta
+	}
+
+	p := make([]byte, size-16-metaOffset)
+	if _, err := r.ReadAt(p, metaOffset); err != nil {
+		return 0, nil, err
+	}
+
+	m, err := parseMeta(p)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !layered {
+		return metaOffset, m, nil
+	}
+
+	u, n := binary.Uvarint(m[metaTableSize])
+	if n < 1 || u < 16 || u > uint64(metaOffset) {
+		return 0, nil, errBadMeta
+	}
+	return int64(u), m, nil
+}
+
+// writeTrailer writes the metadata and the trailer. Files with layers
+// are marked by the layered magic.
+func writeTrailer(w io.Writer, metaOffset int64, m meta) error {
+	magic := fileMagic
+	if _, ok := m[metaLayers]; ok {
+		magic = layeredMagic
+	}
+
+	p := m.AppendTo(nil)
+	p = binary.LittleEndian.AppendUint64(p, uint64(metaOffset))
+	p = append(p, magic...)
+	_, err := w.Write(p)
+	return err
+}
+
+// --------------------------------------------------------------------
+
+// tableIndex contains the max keys and the offsets of all table blocks.
+type tableIndex struct {
+	maxKeys []uint64
+	offsets []int64 // block offsets, followed by the index offset
+}
+
+func readTableIndex(r io.ReaderAt, size int64) (*tableIndex, error) {
+	if size < 16 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	footer := make([]byte, 8)
+	if _, err := r.ReadAt(footer, size-16); err != nil {
+		return nil, err
+	}
+	indexOffset := int64(binary.LittleEndian.Uint64(footer))
+	if indexOffset < 0 || indexOffset > size-16 {
+		return nil, errBadIndex
+	}
+
+	index := make([]byte, size-16-indexOffset)
+	if _, err := r.ReadAt(index, indexOffset); err != nil {
+		return nil, err
+	}
+
+	var (
+		ti     tableIndex
+		maxKey uint64
+		offset int64
+	)
+	for pos := 0; pos < len(index); {
+		u1, n1 := binary.Uvarint(index[pos:])
+		if n1 < 1 {
+			return nil, errBadIndex
+		}
+		u2, n2 := binary.Uvarint(index[pos+n1:])
+		if n2 < 1 {
+			return nil, errBadIndex
+		}
+		pos += n1 + n2
+
+		maxKey += u1
+		offset += int64(u2)
+		ti.maxKeys = append(ti.maxKeys, maxKey)
+		ti.offsets = append(ti.offsets, offset)
+	}
+	ti.offsets = append(ti.offsets, indexOffset)
+	return &ti, nil
+}
+
+// NumBlocks returns the number of blocks.
+func (ti *tableIndex) NumBlocks() int { return len(ti.maxKeys) }
+
+// Search returns the position of the block which may contain key.
+func (ti *tableIndex) Search(key uint64) int {
+	return sort.Search(len(ti.maxKeys), func(i int) bool { return ti.maxKeys[i] >= key })
+}
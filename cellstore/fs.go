@@ -0,0 +1,40 @@
+package cellstore
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+)
+
+// OpenFS opens a reader for a file within fsys, such as an embed.FS.
+// Files which do not implement io.ReaderAt are buffered in memory.
+func OpenFS(fsys fs.FS, name string) (*Reader, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+		return NewReader(bytes.NewReader(data), int64(len(data)))
+	}
+
+	r, err := NewReader(ra, fi.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
@@ -0,0 +1,70 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"io/fs"
+	"testing/fstest"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+// streamFS hides io.ReaderAt implementations of files.
+type streamFS struct{ fs.FS }
+
+func (s streamFS) Open(name string) (fs.File, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return streamFile{f}, nil
+}
+
+type streamFile struct{ fs.File }
+
+var _ = Describe("OpenFS", func() {
+	var fsys fstest.MapFS
+
+	BeforeEach(func() {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriter(buf, &sntable.WriterOptions{BlockSize: 256})
+		for i := 0; i < 8*100; i += 8 {
+			Expect(w.Append(uint64(seedCellID+s2.CellID(i)), []byte("testdata"))).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+
+		fsys = fstest.MapFS{
+			"stores/test.cs": &fstest.MapFile{Data: buf.Bytes()},
+			"stores/bad.cs":  &fstest.MapFile{Data: []byte("not a store")},
+		}
+	})
+
+	It("should open files", func() {
+		r, err := cellstore.OpenFS(fsys, "stores/test.cs")
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		Expect(r.NumBlocks()).To(Equal(5))
+		Expect(r.Get(uint64(seedCellID + 80))).To(Equal([]byte("testdata")))
+		Expect(r.Close()).To(Succeed())
+	})
+
+	It("should buffer files which don't support random access", func() {
+		r, err := cellstore.OpenFS(streamFS{fsys}, "stores/test.cs")
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		Expect(r.Get(uint64(seedCellID + 80))).To(Equal([]byte("testdata")))
+	})
+
+	It("should fail on bad files", func() {
+		_, err := cellstore.OpenFS(fsys, "stores/missing.cs")
+		Expect(err).To(MatchError(fs.ErrNotExist))
+
+		_, err = cellstore.OpenFS(fsys, "stores/bad.cs")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -8,9 +8,7 @@ require (
 	github.com/bsm/gomega v1.11.0
 	github.com/bsm/sntable v0.1.3
 	github.com/golang/geo v0.0.0-20230421003525-6adc56603217
+	github.com/golang/snappy v0.0.4
 )
 
-require (
-	github.com/golang/snappy v0.0.4 // indirect
-	github.com/klauspost/compress v1.17.8 // indirect
-)
+require github.com/klauspost/compress v1.17.8 // indirect
@@ -0,0 +1,53 @@
+package cellstore
+
+import (
+	"context"
+
+	"github.com/golang/geo/s2"
+)
+
+// KeyFunc extracts a group key from a value, e.g. a brand name.
+// Please note that values must not be retained.
+type KeyFunc func(value []byte) []byte
+
+// Group walks all entries within the region and groups them by the key
+// extracted from their values. A nil region groups the whole store. An
+// optional reducer may be used to reduce values.
+func (r *Reader) Group(region s2.Region, key KeyFunc, reduce Reducer) (map[string]Stats, error) {
+	return r.GroupContext(context.Background(), region, key, reduce)
+}
+
+// GroupContext is like Group but aborts with ctx.Err() once
+// the context is cancelled.
+func (r *Reader) GroupContext(ctx context.Context, region s2.Region, key KeyFunc, reduce Reducer) (map[string]Stats, error) {
+	qs := r.newQueryStats("Group")
+	defer r.observe(qs)
+
+	groups := make(map[string]Stats)
+	fn := func(_ s2.CellID, value []byte) error {
+		if qs != nil {
+			qs.EntriesReturned++
+		}
+
+		k := key(value)
+		st := groups[string(k)]
+		st.Count++
+		st.ValueBytes += len(value)
+		if reduce != nil {
+			st.Reduced = reduce(st.Reduced, value)
+		}
+		groups[string(k)] = st
+		return nil
+	}
+
+	var err error
+	if region != nil {
+		err = r.scanRegion(ctx, region, 0, qs, fn)
+	} else {
+		err = r.scanRange(ctx, 0, ^s2.CellID(0), qs, fn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,86 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Grouping", func() {
+	var subject *cellstore.Reader
+
+	origin := cellIDFromDegrees(51.5, -0.12)
+	brand := func(value []byte) []byte {
+		if i := bytes.IndexByte(value, ':'); i > -1 {
+			return value[:i]
+		}
+		return value
+	}
+
+	BeforeEach(func() {
+		entries := make(map[s2.CellID]string)
+		for i := 0; i < 20; i++ {
+			lat := 51.5 + float64(i)*0.01
+			entries[cellIDFromDegrees(lat, -0.12)] = fmt.Sprintf("acme:%d", i)
+			entries[cellIDFromDegrees(lat, -0.125)] = fmt.Sprintf("bolt:%d", i)
+		}
+		entries[cellIDFromDegrees(51.6, -0.2)] = "corp:0"
+		entries[cellIDFromDegrees(48.86, 2.35)] = "acme:paris"
+		subject = seedStore(entries)
+	})
+
+	It("should return the closest entry per key", func() {
+		rs, err := subject.NearbyWithOptions(origin, 10, &cellstore.NearbyOptions{GroupBy: brand})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+
+		Expect(rs.Len()).To(Equal(3))
+		Expect(string(rs.Entries[0].Value)).To(Equal("acme:0"))
+		Expect(rs.Entries[0].CellID).To(Equal(origin))
+		Expect(rs.Entries[0].Count).To(BeNumerically(">=", 1))
+		Expect(string(rs.Entries[1].Value)).To(Equal("bolt:0"))
+		Expect(rs.Entries[1].Count).To(BeNumerically(">=", 1))
+		Expect(string(rs.Entries[2].Value)).To(Equal("corp:0"))
+		Expect(rs.Entries[2].Count).To(Equal(1))
+		Expect(rs.Token).To(BeEmpty())
+
+		rs2, err := subject.NearbyWithOptions(origin, 2, &cellstore.NearbyOptions{GroupBy: brand, MaxDistance: 5000})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs2.Release()
+		Expect(rs2.Len()).To(Equal(2))
+		Expect(string(rs2.Entries[0].Value)).To(Equal("acme:0"))
+		Expect(rs2.Entries[0].Count).To(BeNumerically(">=", 1))
+		Expect(string(rs2.Entries[1].Value)).To(Equal("bolt:0"))
+		Expect(rs2.Entries[1].Count).To(BeNumerically(">=", 1))
+	})
+
+	It("should not paginate grouped queries", func() {
+		rs, err := subject.Nearby(origin, 2)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs.Token).NotTo(BeEmpty())
+
+		_, err = subject.NearbyWithOptions(origin, 2, &cellstore.NearbyOptions{GroupBy: brand, Token: rs.Token})
+		Expect(err).To(MatchError(`cellstore: grouped queries cannot be paginated`))
+	})
+
+	It("should group regions", func() {
+		region := cellstore.DefaultEarth.Cap(origin.Point(), 5000)
+		groups, err := subject.Group(region, brand, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(Equal(map[string]cellstore.Stats{
+			"acme": {Count: 5, ValueBytes: 30},
+			"bolt": {Count: 5, ValueBytes: 30},
+		}))
+
+		groups, err = subject.Group(nil, brand, func(acc float64, _ []byte) float64 { return acc + 0.5 })
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(HaveLen(3))
+		Expect(groups["acme"]).To(Equal(cellstore.Stats{Count: 21, ValueBytes: 140, Reduced: 10.5}))
+		Expect(groups["corp"]).To(Equal(cellstore.Stats{Count: 1, ValueBytes: 6, Reduced: 0.5}))
+	})
+})
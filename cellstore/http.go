@@ -0,0 +1,311 @@
+package cellstore
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HTTPOptions define HTTPReaderAt specific options.
+type HTTPOptions struct {
+	// Client is the HTTP client.
+	// Default: http.DefaultClient
+	Client *http.Client
+
+	// Header contains additional request headers, e.g. for authorization.
+	Header http.Header
+
+	// PageSize is the size of the cached pages in bytes. Reads are
+	// aligned to pages and requests are always made for whole pages.
+	// Default: 64KiB
+	PageSize int
+
+	// CachePages is the maximum number of cached pages.
+	// Default: 256
+	CachePages int
+}
+
+func (o *HTTPOptions) norm() *HTTPOptions {
+	var oo HTTPOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.Client == nil {
+		oo.Client = http.DefaultClient
+	}
+	if oo.PageSize < 1 {
+		oo.PageSize = 64 * 1024
+	}
+	if oo.CachePages < 1 {
+		oo.CachePages = 256
+	}
+	return &oo
+}
+
+// HTTPReaderAt implements io.ReaderAt for remote files, using HTTP range
+// requests. Fetched pages are kept in a local LRU cache.
+type HTTPReaderAt struct {
+	url  string
+	o    *HTTPOptions
+	size int64
+	etag string // only set if returned by the server
+
+	mu    sync.Mutex
+	pages map[int64]*list.Element
+	lru   *list.List
+}
+
+type httpPage struct {
+	pos  int64
+	data []byte
+}
+
+// NewHTTPReaderAt inits a reader for a remote URL. The server must
+// support range requests. If the server returns an ETag, subsequent
+// requests fail once the remote file has changed.
+func NewHTTPReaderAt(url string, o *HTTPOptions) (*HTTPReaderAt, error) {
+	h := &HTTPReaderAt{
+		url:   url,
+		o:     o.norm(),
+		pages: make(map[int64]*list.Element),
+		lru:   list.New(),
+	}
+
+	// fetch the first page to determine the size
+	resp, err := h.fetch(0, int64(h.o.PageSize)-1)
+	if err != nil {
+		return nil, err
+	}
+	h.size = resp.size
+	if !strings.HasPrefix(resp.etag, "W/") {
+		h.etag = resp.etag // weak tags cannot be matched
+	}
+
+	data, err := h.pageData(resp, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	h.store(0, data)
+	return h, nil
+}
+
+// OpenURL opens a reader for a remote file, using HTTP range requests.
+func OpenURL(url string, o *HTTPOptions) (*Reader, error) {
+	h, err := NewHTTPReaderAt(url, o)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewReader(h, h.Size())
+	if err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	r.closer = h
+	return r, nil
+}
+
+// Size returns the size of the remote file.
+func (h *HTTPReaderAt) Size() int64 { return h.size }
+
+// ReadAt implements io.ReaderAt.
+func (h *HTTPReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errNegativeOffset
+	} else if off >= h.size {
+		return 0, io.EOF
+	} else if len(p) == 0 {
+		return 0, nil
+	}
+
+	var eof error
+	end := off + int64(len(p))
+	if end > h.size {
+		end, eof = h.size, io.EOF
+	}
+
+	psz := int64(h.o.PageSize)
+	first, last := off/psz, (end-1)/psz
+	pages, err := h.load(first, last)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i, page := range pages {
+		start := int64(0)
+		if i == 0 {
+			start = off - first*psz
+		}
+		n += copy(p[n:end-off], page[start:])
+	}
+	return n, eof
+}
+
+// Close purges the cache.
+func (h *HTTPReaderAt) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pages = make(map[int64]*list.Element)
+	h.lru.Init()
+	return nil
+}
+
+// load returns pages between first and last (both inclusive), fetching
+// missing pages in a single request.
+func (h *HTTPReaderAt) load(first, last int64) ([][]byte, error) {
+	pages := make([][]byte, last-first+1)
+	mfirst, mlast := int64(-1), int64(-1)
+
+	h.mu.Lock()
+	for pos := first; pos <= last; pos++ {
+		if el, ok := h.pages[pos]; ok {
+			h.lru.MoveToFront(el)
+			pages[pos-first] = el.Value.(*httpPage).data
+		} else {
+			if mfirst < 0 {
+				mfirst = pos
+			}
+			mlast = pos
+		}
+	}
+	h.mu.Unlock()
+
+	if mfirst < 0 {
+		return pages, nil
+	}
+
+	psz := int64(h.o.PageSize)
+	resp, err := h.fetch(mfirst*psz, (mlast+1)*psz-1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := h.pageData(resp, mfirst, mlast)
+	if err != nil {
+		return nil, err
+	}
+
+	for pos := mfirst; pos <= mlast && len(data) != 0; pos++ {
+		page := data
+		if int64(len(page)) > psz {
+			page = page[:psz:psz]
+		}
+		data = data[len(page):]
+
+		h.store(pos, page)
+		pages[pos-first] = page
+	}
+	return pages, nil
+}
+
+func (h *HTTPReaderAt) store(pos int64, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if el, ok := h.pages[pos]; ok {
+		h.lru.MoveToFront(el)
+		return
+	}
+
+	h.pages[pos] = h.lru.PushFront(&httpPage{pos: pos, data: data})
+	for h.lru.Len() > h.o.CachePages {
+		el := h.lru.Back()
+		h.lru.Remove(el)
+		delete(h.pages, el.Value.(*httpPage).pos)
+	}
+}
+
+// pageData validates a response for pages between first and last (both
+// inclusive) and returns its data. Short responses are rejected with
+// io.ErrUnexpectedEOF.
+func (h *HTTPReaderAt) pageData(resp *httpResponse, first, last int64) ([]byte, error) {
+	if resp.size != h.size {
+		return nil, errRemoteChanged
+	}
+
+	psz := int64(h.o.PageSize)
+	start, end := first*psz, (last+1)*psz
+	if end > h.size {
+		end = h.size
+	}
+	if int64(len(resp.data)) < end-start {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return resp.data[:end-start], nil
+}
+
+type httpResponse struct {
+	size int64  // the total size of the file
+	etag string // the ETag of the file
+	data []byte
+}
+
+// fetch fetches a byte range.
+func (h *HTTPReaderAt) fetch(start, end int64) (*httpResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range h.o.Header {
+		req.Header[k] = vv
+	}
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10))
+	if h.etag != "" {
+		req.Header.Set("If-Match", h.etag)
+	}
+
+	resp, err := h.o.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return nil, errNoRangeSupport
+	case http.StatusPreconditionFailed:
+		return nil, errRemoteChanged
+	default:
+		return nil, fmt.Errorf("cellstore: unexpected HTTP status %q", resp.Status)
+	}
+
+	size, err := parseContentRange(resp.Header.Get("Content-Range"), start)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &httpResponse{size: size, etag: resp.Header.Get("ETag"), data: data}, nil
+}
+
+// parseContentRange parses a "bytes start-end/size" header.
+func parseContentRange(s string, start int64) (int64, error) {
+	s = strings.TrimPrefix(s, "bytes ")
+	rng, total, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, errBadContentRange
+	}
+
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, errBadContentRange
+	}
+	if n, err := strconv.ParseInt(first, 10, 64); err != nil || n != start {
+		return 0, errBadContentRange
+	}
+
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size < 0 {
+		return 0, errBadContentRange
+	}
+	return size, nil
+}
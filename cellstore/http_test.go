@@ -0,0 +1,192 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("HTTPReaderAt", func() {
+	var server *httptest.Server
+	var data []byte
+	var numRequests int32
+	var etag atomic.Value
+
+	BeforeEach(func() {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriter(buf, &sntable.WriterOptions{BlockSize: 2048})
+		for i := 0; i < 8*1000; i += 8 {
+			cellID := seedCellID + s2.CellID(i)
+			Expect(w.Append(uint64(cellID), []byte(cellID.String()))).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+		data = buf.Bytes()
+
+		atomic.StoreInt32(&numRequests, 0)
+		etag.Store(`"v1"`)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&numRequests, 1)
+
+			switch r.URL.Path {
+			case "/store.cs":
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+				http.ServeContent(w, r, "store.cs", time.Time{}, bytes.NewReader(data))
+			case "/norange.cs":
+				_, _ = w.Write(data)
+			case "/etag.cs":
+				w.Header().Set("ETag", etag.Load().(string))
+				http.ServeContent(w, r, "etag.cs", time.Time{}, bytes.NewReader(data))
+			case "/short.cs":
+				// truncate all responses, except the first page
+				rec := httptest.NewRecorder()
+				http.ServeContent(rec, r, "short.cs", time.Time{}, bytes.NewReader(data))
+				for k, vv := range rec.Header() {
+					w.Header()[k] = vv
+				}
+				body := rec.Body.Bytes()
+				if !strings.HasPrefix(r.Header.Get("Range"), "bytes=0-") {
+					body = body[:len(body)/2]
+					w.Header().Del("Content-Length")
+				}
+				w.WriteHeader(rec.Code)
+				_, _ = w.Write(body)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	opts := func() *cellstore.HTTPOptions {
+		return &cellstore.HTTPOptions{
+			Header:     http.Header{"Authorization": {"Bearer secret"}},
+			PageSize:   1024,
+			CachePages: 8,
+		}
+	}
+
+	It("should read at", func() {
+		subject, err := cellstore.NewHTTPReaderAt(server.URL+"/store.cs", opts())
+		Expect(err).NotTo(HaveOccurred())
+		defer subject.Close()
+		Expect(subject.Size()).To(Equal(int64(len(data))))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(1)))
+
+		p := make([]byte, 3000)
+		Expect(subject.ReadAt(p, 500)).To(Equal(3000))
+		Expect(p).To(Equal(data[500:3500]))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(2)))
+
+		Expect(subject.ReadAt(p[:100], 1100)).To(Equal(100))
+		Expect(p[:100]).To(Equal(data[1100:1200]))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(2)))
+
+		n, err := subject.ReadAt(p, int64(len(data)-10))
+		Expect(n).To(Equal(10))
+		Expect(err).To(Equal(io.EOF))
+		Expect(p[:10]).To(Equal(data[len(data)-10:]))
+
+		_, err = subject.ReadAt(p, int64(len(data)))
+		Expect(err).To(Equal(io.EOF))
+		_, err = subject.ReadAt(p, -1)
+		Expect(err).To(MatchError(`cellstore: negative offset`))
+	})
+
+	It("should evict pages", func() {
+		o := opts()
+		o.PageSize = 256
+		subject, err := cellstore.NewHTTPReaderAt(server.URL+"/store.cs", o)
+		Expect(err).NotTo(HaveOccurred())
+		defer subject.Close()
+		Expect(len(data)).To(BeNumerically(">", 10*256))
+
+		p := make([]byte, 256)
+		for off := 0; off < 10*256; off += 256 {
+			Expect(subject.ReadAt(p, int64(off))).To(Equal(256))
+		}
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(10)))
+
+		Expect(subject.ReadAt(p, 9*256)).To(Equal(256))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(10)))
+
+		Expect(subject.ReadAt(p, 0)).To(Equal(256))
+		Expect(p).To(Equal(data[:256]))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(11)))
+	})
+
+	It("should open remote stores", func() {
+		r, err := cellstore.OpenURL(server.URL+"/store.cs", opts())
+		Expect(err).NotTo(HaveOccurred())
+		defer r.Close()
+
+		rs, err := r.Nearby(seedCellID+4000, 3)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs.Entries[0].CellID).To(Equal(s2.CellID(seedCellID + 4000)))
+		Expect(string(rs.Entries[0].Value)).To(Equal(rs.Entries[0].CellID.String()))
+
+		n := atomic.LoadInt32(&numRequests)
+		Expect(n).To(BeNumerically("<", 5))
+
+		rs2, err := r.Nearby(seedCellID+4000, 3)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs2.Release()
+		Expect(rs2.Entries).To(Equal(rs.Entries))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(n))
+	})
+
+	It("should reject short responses", func() {
+		subject, err := cellstore.NewHTTPReaderAt(server.URL+"/short.cs", opts())
+		Expect(err).NotTo(HaveOccurred())
+		defer subject.Close()
+
+		p := make([]byte, 100)
+		_, err = subject.ReadAt(p, 2000)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		_, err = subject.ReadAt(p, 2000)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		Expect(atomic.LoadInt32(&numRequests)).To(Equal(int32(3)))
+	})
+
+	It("should fail once remote files change", func() {
+		subject, err := cellstore.NewHTTPReaderAt(server.URL+"/etag.cs", opts())
+		Expect(err).NotTo(HaveOccurred())
+		defer subject.Close()
+
+		p := make([]byte, 100)
+		Expect(subject.ReadAt(p, 2000)).To(Equal(100))
+		Expect(p).To(Equal(data[2000:2100]))
+
+		etag.Store(`"v2"`)
+		Expect(subject.ReadAt(p, 2000)).To(Equal(100))
+		_, err = subject.ReadAt(p, 4000)
+		Expect(err).To(MatchError(`cellstore: remote file has changed`))
+	})
+
+	It("should fail on bad responses", func() {
+		_, err := cellstore.OpenURL(server.URL+"/missing.cs", opts())
+		Expect(err).To(MatchError(`cellstore: unexpected HTTP status "404 Not Found"`))
+
+		_, err = cellstore.OpenURL(server.URL+"/store.cs", nil)
+		Expect(err).To(MatchError(`cellstore: unexpected HTTP status "403 Forbidden"`))
+
+		_, err = cellstore.OpenURL(server.URL+"/norange.cs", opts())
+		Expect(err).To(MatchError(`cellstore: HTTP server does not support range requests`))
+	})
+})
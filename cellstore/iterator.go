@@ -0,0 +1,227 @@
+package cellstore
+
+import (
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+// Iterator streams entries across all blocks, holding at most a single
+// block in memory. Iterators must be released after use.
+type Iterator struct {
+	r   *Reader
+	b   *sntable.BlockReader
+	s   *sntable.SectionReader
+	err error
+
+	reverse bool
+	from    uint64      // upper bound of reverse iterators
+	spos    int         // next section of reverse iterators
+	keys    []uint64    // buffered section of reverse iterators
+	vals    [][]byte    // buffered section of reverse iterators
+	pos     int         // position within the buffered section
+	key     uint64      // current key
+	val     []byte      // current value
+	stats   *QueryStats // only set when observed
+	observe bool        // report stats on release
+
+	released bool
+	release  func() // called on release, if set
+}
+
+// All returns an iterator over all entries, in key order.
+func (r *Reader) All() *Iterator {
+	return &Iterator{r: r, stats: r.newQueryStats("All"), observe: true}
+}
+
+// Reverse returns an iterator over all entries with cell IDs less than or
+// equal to from, in reverse key order.
+func (r *Reader) Reverse(from s2.CellID) *Iterator {
+	it := r.reverse(from, r.newQueryStats("Reverse"))
+	it.observe = true
+	return it
+}
+
+// reverse inits a reverse iterator which records into qs.
+func (r *Reader) reverse(from s2.CellID, qs *QueryStats) *Iterator {
+	it := &Iterator{r: r, reverse: true, from: uint64(from), stats: qs}
+	if r.NumBlocks() == 0 {
+		return it
+	}
+
+	b, err := r.SeekBlock(it.from)
+	if err != nil {
+		it.err = err
+		return it
+	}
+	if b.Pos() >= r.NumBlocks() {
+		b.Release()
+		if b, err = r.GetBlock(r.NumBlocks() - 1); err != nil {
+			it.err = err
+			return it
+		}
+		it.spos = b.NumSections() - 1
+	} else {
+		s := b.SeekSection(it.from)
+		it.spos = s.Pos()
+		s.Release()
+	}
+	it.b = b
+	it.r.observeBlock(it.stats, b)
+	return it
+}
+
+// Next advances the cursor to the next entry.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.released {
+		return false
+	}
+
+	for {
+		if it.reverse && it.pos > 0 {
+			it.pos--
+			it.key, it.val = it.keys[it.pos], it.vals[it.pos]
+			break
+		} else if !it.reverse && it.s != nil && it.s.Next() {
+			it.key, it.val = it.s.Key(), it.s.Value()
+			break
+		}
+
+		var ok bool
+		if it.reverse {
+			ok = it.prevSection()
+		} else {
+			ok = it.nextSection()
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if it.stats != nil {
+		it.stats.EntriesConsidered++
+		it.stats.EntriesReturned++
+	}
+	return true
+}
+
+// CellID returns the cell ID of the current entry.
+func (it *Iterator) CellID() s2.CellID { return s2.CellID(it.key) }
+
+// Value returns the value of the current entry. Values are only valid
+// until the next cursor move and must be copied otherwise.
+func (it *Iterator) Value() []byte { return it.val }
+
+// Err exposes errors.
+func (it *Iterator) Err() error { return it.err }
+
+// Release releases the iterator and all held resources.
+func (it *Iterator) Release() {
+	if it.released {
+		return
+	}
+	it.released = true
+
+	if it.s != nil {
+		it.s.Release()
+		it.s = nil
+	}
+	if it.b != nil {
+		it.b.Release()
+		it.b = nil
+	}
+	if it.observe {
+		it.r.observe(it.stats)
+	}
+	if it.release != nil {
+		it.release()
+	}
+}
+
+// Seq returns a push iterator over the remaining entries, which can be
+// used with range-over-func loops. The iterator is released once the loop
+// ends, errors must be checked via Err afterwards.
+func (it *Iterator) Seq() func(yield func(s2.CellID, []byte) bool) {
+	return func(yield func(s2.CellID, []byte) bool) {
+		defer it.Release()
+
+		for it.Next() {
+			if !yield(it.CellID(), it.Value()) {
+				return
+			}
+		}
+	}
+}
+
+func (it *Iterator) nextSection() bool {
+	spos := 0
+	if it.s != nil {
+		spos = it.s.Pos() + 1
+		it.s.Release()
+		it.s = nil
+	}
+
+	if it.b == nil || spos >= it.b.NumSections() {
+		bpos := 0
+		if it.b != nil {
+			bpos = it.b.Pos() + 1
+			it.b.Release()
+			it.b = nil
+		}
+		if bpos >= it.r.NumBlocks() {
+			return false
+		}
+		if !it.getBlock(bpos) {
+			return false
+		}
+		spos = 0
+	}
+
+	it.s = it.b.GetSection(spos)
+	if it.stats != nil {
+		it.stats.SectionsScanned++
+	}
+	return true
+}
+
+func (it *Iterator) prevSection() bool {
+	for it.b != nil {
+		if it.spos < 0 {
+			bpos := it.b.Pos() - 1
+			it.b.Release()
+			it.b = nil
+			if bpos < 0 || !it.getBlock(bpos) {
+				return false
+			}
+			it.spos = it.b.NumSections() - 1
+		}
+
+		s := it.b.GetSection(it.spos)
+		it.spos--
+		if it.stats != nil {
+			it.stats.SectionsScanned++
+		}
+
+		it.keys, it.vals = it.keys[:0], it.vals[:0]
+		for s.Next() && s.Key() <= it.from {
+			it.keys = append(it.keys, s.Key())
+			it.vals = append(it.vals, s.Value())
+		}
+		s.Release()
+
+		if it.pos = len(it.keys); it.pos != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *Iterator) getBlock(bpos int) bool {
+	b, err := it.r.GetBlock(bpos)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.b = b
+	it.r.observeBlock(it.stats, b)
+	return true
+}
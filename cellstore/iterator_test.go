@@ -0,0 +1,79 @@
+package cellstore_test
+
+import (
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Iterator", func() {
+	var subject *cellstore.Reader
+
+	collect := func(it *cellstore.Iterator) []s2.CellID {
+		defer it.Release()
+
+		var res []s2.CellID
+		for it.Next() {
+			Expect(string(it.Value()[:32])).To(Equal(it.CellID().String()))
+			res = append(res, it.CellID())
+		}
+		Expect(it.Err()).NotTo(HaveOccurred())
+		return res
+	}
+
+	BeforeEach(func() {
+		subject = seedInMem(100)
+	})
+
+	It("should iterate over all entries", func() {
+		res := collect(subject.All())
+		Expect(res).To(HaveLen(100))
+		Expect(res[0]).To(Equal(s2.CellID(seedCellID)))
+		Expect(res[99]).To(Equal(s2.CellID(seedCellID + 792)))
+		for i := 1; i < len(res); i++ {
+			Expect(res[i]).To(BeNumerically(">", res[i-1]))
+		}
+
+		Expect(collect(seedInMem(0).All())).To(BeEmpty())
+	})
+
+	It("should iterate in reverse", func() {
+		res := collect(subject.Reverse(seedCellID + 404))
+		Expect(res).To(HaveLen(51))
+		Expect(res[0]).To(Equal(s2.CellID(seedCellID + 400)))
+		Expect(res[50]).To(Equal(s2.CellID(seedCellID)))
+		for i := 1; i < len(res); i++ {
+			Expect(res[i]).To(BeNumerically("<", res[i-1]))
+		}
+
+		res = collect(subject.Reverse(s2.CellIDFromFace(5).ChildEnd()))
+		Expect(res).To(HaveLen(100))
+		Expect(res[0]).To(Equal(s2.CellID(seedCellID + 792)))
+
+		Expect(collect(subject.Reverse(seedCellID + 8))).To(Equal([]s2.CellID{seedCellID + 8, seedCellID}))
+		Expect(collect(subject.Reverse(seedCellID - 8))).To(BeEmpty())
+		Expect(collect(seedInMem(0).Reverse(seedCellID))).To(BeEmpty())
+	})
+
+	It("should support push iteration", func() {
+		it := subject.All()
+		var res []s2.CellID
+		it.Seq()(func(cellID s2.CellID, _ []byte) bool {
+			res = append(res, cellID)
+			return len(res) < 3
+		})
+		Expect(res).To(Equal([]s2.CellID{seedCellID, seedCellID + 8, seedCellID + 16}))
+		Expect(it.Next()).To(BeFalse())
+		Expect(it.Err()).NotTo(HaveOccurred())
+
+		it = subject.Reverse(seedCellID + 16)
+		res = res[:0]
+		it.Seq()(func(cellID s2.CellID, _ []byte) bool {
+			res = append(res, cellID)
+			return true
+		})
+		Expect(res).To(Equal([]s2.CellID{seedCellID + 16, seedCellID + 8, seedCellID}))
+		it.Release()
+	})
+})
@@ -0,0 +1,90 @@
+package cellstore
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// Layers returns the names of all layers, sorted.
+func (r *Reader) Layers() []string {
+	names := make([]string, 0, len(r.layers))
+	for name := range r.layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Layer returns a reader for a named layer, with the same options as r.
+// Layer readers share the underlying file and must not be closed.
+func (r *Reader) Layer(name string) (*Reader, error) {
+	pos, ok := r.layers[name]
+	if !ok {
+		return nil, errUnknownLayer
+	}
+
+	r.layerMu.Lock()
+	defer r.layerMu.Unlock()
+
+	if lr, ok := r.layerReaders[name]; ok {
+		return lr, nil
+	}
+
+	lr, err := NewReaderWithOptions(io.NewSectionReader(r.src, pos[0], pos[1]), pos[1], r.opts)
+	if err != nil {
+		return nil, err
+	}
+	if r.layerReaders == nil {
+		r.layerReaders = make(map[string]*Reader)
+	}
+	r.layerReaders[name] = lr
+	return lr, nil
+}
+
+// appendLayers encodes layer offsets and sizes, sorted by name.
+func appendLayers(dst []byte, layers map[string][2]int64) []byte {
+	names := make([]string, 0, len(layers))
+	for name := range layers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dst = binary.AppendUvarint(dst, uint64(len(names)))
+	for _, name := range names {
+		dst = binary.AppendUvarint(dst, uint64(len(name)))
+		dst = append(dst, name...)
+		dst = binary.AppendUvarint(dst, uint64(layers[name][0]))
+		dst = binary.AppendUvarint(dst, uint64(layers[name][1]))
+	}
+	return dst
+}
+
+func parseLayers(p []byte) (map[string][2]int64, error) {
+	num, n := binary.Uvarint(p)
+	if n < 1 || num > uint64(len(p)) {
+		return nil, errBadMeta
+	}
+	p = p[n:]
+
+	layers := make(map[string][2]int64, int(num))
+	for i := uint64(0); i < num; i++ {
+		name, rest, err := readBytes(p)
+		if err != nil {
+			return nil, err
+		}
+
+		offset, n1 := binary.Uvarint(rest)
+		if n1 < 1 {
+			return nil, errBadMeta
+		}
+		size, n2 := binary.Uvarint(rest[n1:])
+		if n2 < 1 || offset > 1<<62 || size > 1<<62 {
+			return nil, errBadMeta
+		}
+
+		layers[string(name)] = [2]int64{int64(offset), int64(size)}
+		p = rest[n1+n2:]
+	}
+	return layers, nil
+}
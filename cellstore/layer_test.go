@@ -0,0 +1,136 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Layers", func() {
+	var buf *bytes.Buffer
+
+	appendEntries := func(w *cellstore.Writer, value string) {
+		for i := 0; i < 8*200; i += 8 {
+			Expect(w.Append(uint64(seedCellID+s2.CellID(i)), []byte(value))).To(Succeed())
+		}
+	}
+
+	open := func(o *cellstore.ReaderOptions) *cellstore.Reader {
+		r, err := cellstore.NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), o)
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	BeforeEach(func() {
+		buf = new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{
+			WriterOptions: sntable.WriterOptions{BlockSize: 512},
+			FilterFPRate:  0.01,
+		})
+		appendEntries(w, "main")
+
+		for _, name := range []string{"pois", "transit", "parking"} {
+			lw, err := w.Layer(name, &cellstore.WriterOptions{
+				WriterOptions: sntable.WriterOptions{BlockSize: 256},
+				ContentHash:   name == "transit",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			if name != "parking" {
+				appendEntries(lw, name)
+			}
+			Expect(lw.Close()).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+	})
+
+	It("should read layers", func() {
+		r := open(nil)
+		Expect(r.Layers()).To(Equal([]string{"parking", "pois", "transit"}))
+		Expect(r.Get(uint64(seedCellID + 80))).To(Equal([]byte("main")))
+		Expect(r.ContentHash()).To(BeNil())
+
+		pois, err := r.Layer("pois")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pois.Layers()).To(BeEmpty())
+		Expect(pois.Get(uint64(seedCellID + 80))).To(Equal([]byte("pois")))
+		Expect(pois.ContentHash()).To(BeNil())
+
+		rs, err := pois.Nearby(seedCellID+800, 3)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs.Len()).To(Equal(3))
+		Expect(string(rs.Entries[0].Value)).To(Equal("pois"))
+
+		transit, err := r.Layer("transit")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transit.Get(uint64(seedCellID + 80))).To(Equal([]byte("transit")))
+		Expect(transit.ContentHash()).To(HaveLen(32))
+
+		parking, err := r.Layer("parking")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = parking.Get(uint64(seedCellID + 80))
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+
+		again, err := r.Layer("pois")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(BeIdenticalTo(pois))
+
+		_, err = r.Layer("missing")
+		Expect(err).To(MatchError(`cellstore: unknown layer`))
+	})
+
+	It("should mark layered files", func() {
+		Expect(buf.Bytes()[buf.Len()-8:]).To(Equal([]byte{99, 115, 14, 17, 90, 79, 157, 2}))
+
+		plain := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(plain, &cellstore.WriterOptions{FilterFPRate: 0.01})
+		appendEntries(w, "main")
+		Expect(w.Close()).To(Succeed())
+		Expect(plain.Bytes()[plain.Len()-8:]).To(Equal([]byte{99, 115, 14, 17, 90, 79, 157, 1}))
+	})
+
+	It("should inherit reader options", func() {
+		observer := new(mockObserver)
+		r := open(&cellstore.ReaderOptions{Observer: observer})
+
+		transit, err := r.Layer("transit")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(transit.Get(uint64(seedCellID + 80))).To(Equal([]byte("transit")))
+		Expect(observer.stats).To(HaveLen(1))
+		Expect(observer.stats[0].BlocksFetched).To(Equal(1))
+	})
+
+	It("should validate writes", func() {
+		w := cellstore.NewWriterWithOptions(new(bytes.Buffer), nil)
+		appendEntries(w, "main")
+
+		lw, err := w.Layer("a", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(w.Append(uint64(seedCellID+8000), []byte("main"))).To(MatchError(`cellstore: table is closed`))
+
+		_, err = w.Layer("b", nil)
+		Expect(err).To(MatchError(`cellstore: layer is still open`))
+		Expect(w.Close()).To(MatchError(`cellstore: layer is still open`))
+
+		Expect(lw.Close()).To(Succeed())
+		_, err = w.Layer("a", nil)
+		Expect(err).To(MatchError(`cellstore: duplicate layer name`))
+		Expect(w.Close()).To(Succeed())
+	})
+
+	It("should not extract layers", func() {
+		out := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(out, nil)
+		Expect(cellstore.Extract(open(nil), s2.CellFromCellID(s2.CellID(seedCellID).Parent(10)), w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		x, err := cellstore.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(x.Layers()).To(BeEmpty())
+		Expect(x.Get(uint64(seedCellID + 80))).To(Equal([]byte("main")))
+	})
+})
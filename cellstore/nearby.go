@@ -1,7 +1,7 @@
 package cellstore
 
 import (
-	"sort"
+	"math"
 	"sync"
 
 	"github.com/golang/geo/s1"
@@ -13,7 +13,21 @@ var nearbyRSPool sync.Pool
 // NearbyRS is the nearby result set.
 type NearbyRS struct {
 	Entries []NearbyEntry
-	buf     []byte
+
+	// Truncated is set by capped queries, such as WithinRect,
+	// if more entries could have been returned.
+	Truncated bool
+
+	// Earth is the model which metric distances of the query were
+	// converted with.
+	Earth Earth
+
+	// Token is a continuation token for the next page of results.
+	// It is empty if no further results are available.
+	Token Token
+
+	byScore bool   // rank entries by score
+	release func() // called on release, if set
 }
 
 func newNearbyRS() *NearbyRS {
@@ -30,51 +44,225 @@ func (n *NearbyRS) Len() int {
 	return len(n.Entries)
 }
 
-// Reset resets the set.
+// Reset resets the set. Value buffers of the entries are retained
+// and reused by subsequent queries.
 func (n *NearbyRS) Reset() {
 	if n != nil {
 		n.Entries = n.Entries[:0]
-		n.buf = n.buf[:0]
+		n.Truncated = false
+		n.Earth = Earth{}
+		n.Token = n.Token[:0]
+		n.byScore = false
 	}
 }
 
-// Release releases the result set.
+// Release releases the result set to the pool. Result sets
+// passed to Reader.NearbyInto are owned by the caller and
+// don't need to be released.
 func (n *NearbyRS) Release() {
 	if n != nil {
+		if fn := n.release; fn != nil {
+			n.release = nil
+			fn()
+		}
 		nearbyRSPool.Put(n)
 	}
 }
 
 func (n *NearbyRS) add(cellID s2.CellID, value []byte, distance s1.Angle) {
-	off := len(n.buf)
-	n.buf = append(n.buf, value...)
-	n.Entries = append(n.Entries, NearbyEntry{
-		CellID:   cellID,
-		Value:    n.buf[off:len(n.buf)],
-		Distance: distance,
-	})
+	n.addEntry(&NearbyEntry{CellID: cellID, Value: value, Distance: distance})
+}
+
+func (n *NearbyRS) addEntry(ent *NearbyEntry) {
+	if sz := len(n.Entries); sz < cap(n.Entries) {
+		n.Entries = n.Entries[:sz+1]
+	} else {
+		n.Entries = append(n.Entries, NearbyEntry{})
+	}
+	e := &n.Entries[len(n.Entries)-1]
+	e.set(ent)
+	e.earth = n.Earth
 }
 
+// push adds an entry to a heap of at most limit entries, keeping the
+// best ranked ones only. It returns false if an entry had to be discarded.
+func (n *NearbyRS) push(ent *NearbyEntry, limit int) bool {
+	if len(n.Entries) < limit {
+		n.addEntry(ent)
+		n.up(len(n.Entries) - 1)
+		return true
+	} else if limit < 1 {
+		return false
+	}
+
+	if root := &n.Entries[0]; n.rankLess(ent, root) {
+		root.set(ent)
+		root.earth = n.Earth
+		n.down(0, len(n.Entries))
+	}
+	return false
+}
+
+// maxDistance returns the distance of the furthest entry of a full heap.
+func (n *NearbyRS) maxDistance(limit int) s1.Angle {
+	if limit < 1 || len(n.Entries) < limit {
+		return s1.InfAngle()
+	}
+	return n.Entries[0].Distance
+}
+
+// minScore returns the score of the worst ranked entry of a full heap.
+func (n *NearbyRS) minScore(limit int) float64 {
+	if limit < 1 || len(n.Entries) < limit {
+		return math.Inf(-1)
+	}
+	return n.Entries[0].Score
+}
+
+// sort sorts a heap in place by rank.
 func (n *NearbyRS) sort() {
-	sort.Sort(nearbyEntrySlice(n.Entries))
+	for i := len(n.Entries) - 1; i > 0; i-- {
+		n.Entries[0], n.Entries[i] = n.Entries[i], n.Entries[0]
+		n.down(0, i)
+	}
+}
+
+func (n *NearbyRS) less(i, j int) bool {
+	return n.rankLess(&n.Entries[i], &n.Entries[j])
+}
+
+// rankLess returns true if a ranks before b, i.e. if it has a higher score
+// or if it is closer.
+func (n *NearbyRS) rankLess(a, b *NearbyEntry) bool {
+	if n.byScore && a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Distance == b.Distance {
+		return a.CellID < b.CellID
+	}
+	return a.Distance < b.Distance
 }
 
-func (n *NearbyRS) limit(limit int) {
-	if limit < len(n.Entries) {
-		n.Entries = n.Entries[:limit]
+func (n *NearbyRS) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !n.less(p, i) {
+			break
+		}
+		n.Entries[p], n.Entries[i] = n.Entries[i], n.Entries[p]
+		i = p
 	}
 }
 
+func (n *NearbyRS) down(i, sz int) {
+	for {
+		c := 2*i + 1
+		if c >= sz {
+			break
+		}
+		if r := c + 1; r < sz && n.less(c, r) {
+			c = r
+		}
+		if !n.less(i, c) {
+			break
+		}
+		n.Entries[i], n.Entries[c] = n.Entries[c], n.Entries[i]
+		i = c
+	}
+}
+
+func (n *NearbyRS) calcBearings(origin s2.LatLng) {
+	for i := range n.Entries {
+		if e := &n.Entries[i]; e.Distance != 0 {
+			e.Bearing = initialBearing(origin, e.CellID.LatLng())
+		}
+	}
+}
+
+// NearbyOptions define optional parameters for nearby searches.
+type NearbyOptions struct {
+	// MaxDistance limits results to entries within the given
+	// distance in metres, as measured on Earth.
+	// Default: 0 (unlimited)
+	MaxDistance float64
+
+	// Earth is the model used to convert metric distances.
+	// Default: the Earth of the reader
+	Earth Earth
+
+	// Neighbors enables additional scans of the cells neighbouring the
+	// query point. By default, entries are only searched along the
+	// Hilbert curve order around the query point, which may miss close
+	// entries across face or large parent cell boundaries. Neighbour
+	// scans are limited to the cells within ~94km of the query point.
+	Neighbors bool
+
+	// GroupBy groups entries by a key, extracted from their values. Only the
+	// closest entry of each group is returned, along with the number of
+	// considered entries of the group. Since nearby searches only consider
+	// entries around the query point, counts are a lower bound; use
+	// Reader.Group for exact counts within a region. Entries of known groups
+	// count towards the searched window, so fewer than limit groups may be
+	// returned. Grouped queries are not paginated.
+	// Default: nil (no grouping)
+	GroupBy KeyFunc
+
+	// Token resumes a paginated query, it must be taken from the
+	// result set of the previous page of the same query. Subsequent pages
+	// continue along the Hilbert curve order from where the previous page
+	// ended, they may contain entries which are closer than the entries
+	// of previous pages. Neighbouring cells are only scanned for the
+	// first page.
+	Token Token
+}
+
+func (o *NearbyOptions) norm() *NearbyOptions {
+	var oo NearbyOptions
+	if o != nil {
+		oo = *o
+	}
+	return &oo
+}
+
+// maxAngle returns the maximum distance as an angle.
+func (o *NearbyOptions) maxAngle(earth Earth) s1.Angle {
+	if o.MaxDistance > 0 {
+		return earth.Angle(o.MaxDistance)
+	}
+	return s1.InfAngle()
+}
+
 // NearbyEntry is returned by Nearby search.
 type NearbyEntry struct {
 	s2.CellID
 	Value    []byte
 	Distance s1.Angle
+
+	// Bearing is the initial bearing from the query point to the entry,
+	// measured clockwise from north. It is zero for entries at the query point.
+	Bearing s1.Angle
+
+	// Score is only set by scored queries.
+	Score float64
+
+	// Count is the number of considered entries which share the same
+	// group key. It is only set by grouped queries.
+	Count int
+
+	earth Earth // model of the query
 }
 
-// NearbyEntrySlice is a slice of nearby entries.
-type nearbyEntrySlice []NearbyEntry
+// Meters returns the distance in metres, as measured on the Earth model
+// of the query.
+func (e *NearbyEntry) Meters() float64 { return e.earth.or(DefaultEarth).Meters(e.Distance) }
+
+// Kilometers returns the distance in kilometres, as measured on the Earth
+// model of the query.
+func (e *NearbyEntry) Kilometers() float64 { return e.earth.or(DefaultEarth).Kilometers(e.Distance) }
 
-func (s nearbyEntrySlice) Len() int           { return len(s) }
-func (s nearbyEntrySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-func (s nearbyEntrySlice) Less(i, j int) bool { return s[i].Distance < s[j].Distance }
+// set copies src into the entry, reusing the value buffer.
+func (e *NearbyEntry) set(src *NearbyEntry) {
+	value := append(e.Value[:0], src.Value...)
+	*e = *src
+	e.Value = value
+}
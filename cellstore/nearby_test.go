@@ -32,4 +32,29 @@ var _ = Describe("NearbyRS", func() {
 				{CellID: 1317624576600000249, Distance: 70},
 			}))
 		})
+
+	It("should keep the closest entries", func() {
+		rs := new(cellstore.NearbyRS)
+		Expect(rs.Push(1317624576600000345, []byte("a"), 60, 3)).To(BeTrue())
+		Expect(rs.Push(1317624576600000321, []byte("b"), 30, 3)).To(BeTrue())
+		Expect(rs.Push(1317624576600000305, []byte("c"), 10, 3)).To(BeTrue())
+		Expect(rs.Push(1317624576600000289, []byte("d"), 20, 3)).To(BeFalse())
+		Expect(rs.Push(1317624576600000257, []byte("e"), 40, 3)).To(BeFalse())
+		Expect(rs.Push(1317624576600000249, []byte("f"), 10, 3)).To(BeFalse())
+		rs.Sort()
+
+		Expect(rs.Entries).To(Equal([]cellstore.NearbyEntry{
+			{CellID: 1317624576600000249, Value: []byte("f"), Distance: 10},
+			{CellID: 1317624576600000305, Value: []byte("c"), Distance: 10},
+			{CellID: 1317624576600000289, Value: []byte("d"), Distance: 20},
+		}))
+
+		rs.Reset()
+		Expect(rs.Push(1317624576600000241, []byte("g"), 50, 1)).To(BeTrue())
+		Expect(rs.Push(1317624576600000345, nil, 60, 1)).To(BeFalse())
+		Expect(rs.Entries).To(Equal([]cellstore.NearbyEntry{
+			{CellID: 1317624576600000241, Value: []byte("g"), Distance: 50},
+		}))
+		Expect(rs.Push(1317624576600000345, nil, 60, 0)).To(BeFalse())
+	})
 })
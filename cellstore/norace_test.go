@@ -0,0 +1,5 @@
+//go:build !race
+
+package cellstore_test
+
+const raceEnabled = false
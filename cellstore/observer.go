@@ -0,0 +1,128 @@
+package cellstore
+
+import (
+	"expvar"
+	"io"
+	"sort"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+)
+
+// QueryStats contain the statistics of a single query.
+type QueryStats struct {
+	// Query is the name of the query method, e.g. "Nearby".
+	Query string
+
+	// BlocksFetched is the number of blocks fetched from the underlying reader.
+	BlocksFetched int
+	// BytesRead is the number of (compressed) bytes read.
+	BytesRead int64
+	// BytesDecompressed is the number of bytes of the decompressed blocks.
+	BytesDecompressed int64
+	// SectionsScanned is the number of scanned sections.
+	SectionsScanned int
+
+	// EntriesConsidered is the number of entries which were read.
+	EntriesConsidered int
+	// EntriesReturned is the number of entries which were returned.
+	EntriesReturned int
+
+	// FilterChecks is the number of block filter checks.
+	FilterChecks int
+	// FilterNegatives is the number of blocks skipped by filters.
+	FilterNegatives int
+	// FilterFalsePositives is the number of blocks which were read
+	// after a filter check, but didn't contain the key.
+	FilterFalsePositives int
+}
+
+// Observer instances are notified about the statistics of each query.
+// Implementations must be safe for concurrent use and must not retain
+// the stats.
+type Observer interface {
+	ObserveQuery(*QueryStats)
+}
+
+// ExpvarObserver is an Observer which publishes query statistics
+// as counters of an expvar.Map.
+type ExpvarObserver struct {
+	m *expvar.Map
+}
+
+// NewExpvarObserver creates a new observer and publishes its counters
+// under name. Like expvar.Publish, it panics if the name is already
+// registered.
+func NewExpvarObserver(name string) *ExpvarObserver {
+	return &ExpvarObserver{m: expvar.NewMap(name)}
+}
+
+// Map returns the published map.
+func (o *ExpvarObserver) Map() *expvar.Map { return o.m }
+
+// ObserveQuery implements Observer.
+func (o *ExpvarObserver) ObserveQuery(s *QueryStats) {
+	o.m.Add("queries", 1)
+	o.m.Add("queries."+s.Query, 1)
+	o.m.Add("blocks_fetched", int64(s.BlocksFetched))
+	o.m.Add("bytes_read", s.BytesRead)
+	o.m.Add("bytes_decompressed", s.BytesDecompressed)
+	o.m.Add("sections_scanned", int64(s.SectionsScanned))
+	o.m.Add("entries_considered", int64(s.EntriesConsidered))
+	o.m.Add("entries_returned", int64(s.EntriesReturned))
+	o.m.Add("filter_checks", int64(s.FilterChecks))
+	o.m.Add("filter_negatives", int64(s.FilterNegatives))
+	o.m.Add("filter_false_positives", int64(s.FilterFalsePositives))
+}
+
+// --------------------------------------------------------------------
+
+// blockStats wraps the underlying reader and records the
+// sizes of all blocks as they are read.
+type blockStats struct {
+	io.ReaderAt
+
+	offsets []int64 // block offsets, followed by the index offset
+	decoded []int64 // decompressed block sizes, populated on read
+}
+
+func newBlockStats(r io.ReaderAt, ti *tableIndex) *blockStats {
+	return &blockStats{
+		ReaderAt: r,
+		offsets:  ti.offsets,
+		decoded:  make([]int64, ti.NumBlocks()),
+	}
+}
+
+// ReadAt implements io.ReaderAt.
+func (b *blockStats) ReadAt(p []byte, off int64) (int, error) {
+	n, err := b.ReaderAt.ReadAt(p, off)
+	if err != nil || len(p) == 0 {
+		return n, err
+	}
+
+	bpos := sort.Search(len(b.decoded), func(i int) bool { return b.offsets[i] >= off })
+	if bpos == len(b.decoded) || b.offsets[bpos] != off || b.offsets[bpos+1]-off != int64(len(p)) {
+		return n, err
+	}
+
+	sz := int64(len(p) - 1)
+	if p[len(p)-1] == 1 { // snappy compressed
+		if n, err := snappy.DecodedLen(p[:len(p)-1]); err == nil {
+			sz = int64(n)
+		}
+	}
+	atomic.StoreInt64(&b.decoded[bpos], sz)
+	return n, err
+}
+
+// Observe adds the stats of a fetched block.
+func (b *blockStats) Observe(s *QueryStats, bpos int) {
+	if bpos < 0 || bpos >= len(b.decoded) {
+		return
+	}
+
+	s.BlocksFetched++
+	s.BytesRead += b.offsets[bpos+1] - b.offsets[bpos]
+	s.BytesDecompressed += atomic.LoadInt64(&b.decoded[bpos])
+}
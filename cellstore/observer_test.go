@@ -0,0 +1,156 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+type mockObserver struct {
+	mu    sync.Mutex
+	stats []cellstore.QueryStats
+}
+
+func (o *mockObserver) ObserveQuery(s *cellstore.QueryStats) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.stats = append(o.stats, *s)
+}
+
+// blockObserver records the number of blocks fetched by the last query,
+// without allocating.
+type blockObserver struct {
+	BlocksFetched int
+}
+
+func (o *blockObserver) ObserveQuery(s *cellstore.QueryStats) { o.BlocksFetched = s.BlocksFetched }
+
+var _ = Describe("Observer", func() {
+	var subject *cellstore.Reader
+	var observer *mockObserver
+
+	open := func(compression sntable.Compression) *cellstore.Reader {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriter(buf, &sntable.WriterOptions{BlockSize: 2048, Compression: compression})
+		for i := 0; i < 8*1000; i += 8 {
+			Expect(w.Append(uint64(seedCellID+s2.CellID(i)), bytes.Repeat([]byte("x"), 64))).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+
+		r, err := cellstore.NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &cellstore.ReaderOptions{
+			Observer: observer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	BeforeEach(func() {
+		observer = new(mockObserver)
+		subject = open(sntable.SnappyCompression)
+	})
+
+	It("should bound grouped nearby queries", func() {
+		rs, err := subject.Nearby(seedCellID+4000, 10)
+		Expect(err).NotTo(HaveOccurred())
+		rs.Release()
+
+		single := func([]byte) []byte { return []byte("x") }
+		rs, err = subject.NearbyWithOptions(seedCellID+4000, 10, &cellstore.NearbyOptions{GroupBy: single})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(1))
+		Expect(rs.Entries[0].Count).To(Equal(56))
+		rs.Release()
+
+		Expect(observer.stats).To(HaveLen(2))
+		Expect(observer.stats[1].BlocksFetched).To(Equal(observer.stats[0].BlocksFetched))
+		Expect(observer.stats[1].EntriesConsidered).To(Equal(observer.stats[0].EntriesConsidered))
+	})
+
+	It("should observe nearby queries", func() {
+		rs, err := subject.Nearby(seedCellID+4000, 10)
+		Expect(err).NotTo(HaveOccurred())
+		rs.Release()
+
+		Expect(observer.stats).To(HaveLen(1))
+		stats := observer.stats[0]
+		Expect(stats.Query).To(Equal("Nearby"))
+		Expect(stats.BlocksFetched).To(Equal(4))
+		Expect(stats.BytesRead).To(Equal(int64(500)))
+		Expect(stats.BytesDecompressed).To(Equal(int64(8016)))
+		Expect(stats.SectionsScanned).To(Equal(6))
+		Expect(stats.EntriesConsidered).To(Equal(56))
+		Expect(stats.EntriesReturned).To(Equal(10))
+	})
+
+	It("should observe uncompressed reads", func() {
+		subject = open(sntable.NoCompression)
+		_, err := subject.WithinRect(s2.FullRect(), 100)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(observer.stats).To(HaveLen(1))
+		stats := observer.stats[0]
+		Expect(stats.Query).To(Equal("WithinRect"))
+		Expect(stats.BlocksFetched).To(Equal(4))
+		Expect(stats.BytesDecompressed).To(Equal(stats.BytesRead - 4))
+		Expect(stats.EntriesConsidered).To(Equal(101))
+		Expect(stats.EntriesReturned).To(Equal(100))
+	})
+
+	It("should observe iterators on release", func() {
+		iter, err := subject.FindSection(seedCellID)
+		Expect(err).NotTo(HaveOccurred())
+		for iter.Next() {
+		}
+		Expect(iter.NextSection()).To(BeTrue())
+		for iter.Next() {
+		}
+		Expect(observer.stats).To(BeEmpty())
+
+		iter.Release()
+		Expect(observer.stats).To(HaveLen(1))
+		stats := observer.stats[0]
+		Expect(stats.Query).To(Equal("FindSection"))
+		Expect(stats.BlocksFetched).To(Equal(1))
+		Expect(stats.SectionsScanned).To(Equal(2))
+		Expect(stats.EntriesConsidered).To(Equal(30))
+		Expect(stats.EntriesReturned).To(Equal(30))
+	})
+
+	It("should observe aggregations", func() {
+		_, err := subject.Aggregate(nil, 10, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(observer.stats).To(HaveLen(1))
+		stats := observer.stats[0]
+		Expect(stats.Query).To(Equal("Aggregate"))
+		Expect(stats.BlocksFetched).To(Equal(subject.NumBlocks()))
+		Expect(stats.EntriesConsidered).To(Equal(1000))
+		Expect(stats.EntriesReturned).To(Equal(1000))
+	})
+
+	It("should publish to expvar", func() {
+		xo := cellstore.NewExpvarObserver("cellstore_test")
+		xo.ObserveQuery(&cellstore.QueryStats{Query: "Nearby", BlocksFetched: 2, BytesRead: 100, EntriesConsidered: 30, EntriesReturned: 10})
+		xo.ObserveQuery(&cellstore.QueryStats{Query: "Nearby", BlocksFetched: 1, BytesRead: 50, EntriesConsidered: 20, EntriesReturned: 10})
+		xo.ObserveQuery(&cellstore.QueryStats{Query: "Get", FilterChecks: 1, FilterNegatives: 1})
+		Expect(xo.Map().String()).To(MatchJSON(`{
+			"blocks_fetched": 3,
+			"bytes_decompressed": 0,
+			"bytes_read": 150,
+			"entries_considered": 50,
+			"entries_returned": 20,
+			"filter_checks": 1,
+			"filter_false_positives": 0,
+			"filter_negatives": 1,
+			"queries": 3,
+			"queries.Get": 1,
+			"queries.Nearby": 2,
+			"sections_scanned": 0
+		}`))
+	})
+})
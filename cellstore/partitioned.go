@@ -0,0 +1,180 @@
+package cellstore
+
+import (
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/golang/geo/s2"
+)
+
+// PartitionedBuilderOptions define PartitionedBuilder specific options.
+type PartitionedBuilderOptions struct {
+	BuilderOptions
+
+	// Level is the level of the parent cells by which entries are
+	// partitioned into 6<<(2*Level) partitions. Each partition is sorted
+	// separately and BufferSize is split evenly between them, though
+	// each partition buffers at least 64KiB. Values above 4 are capped.
+	// Default: 0 (partition by face)
+	Level int
+
+	// Concurrency limits the number of partitions which are sorted
+	// concurrently.
+	// Default: runtime.NumCPU()
+	Concurrency int
+}
+
+func (o *PartitionedBuilderOptions) norm() *PartitionedBuilderOptions {
+	var oo PartitionedBuilderOptions
+	if o != nil {
+		oo = *o
+	}
+	oo.BuilderOptions = *oo.BuilderOptions.norm()
+	if oo.Level < 0 {
+		oo.Level = 0
+	} else if oo.Level > 4 {
+		oo.Level = 4
+	}
+	if oo.Concurrency < 1 {
+		oo.Concurrency = runtime.NumCPU()
+	}
+	return &oo
+}
+
+// PartitionedBuilder is like Builder but partitions entries by parent
+// cell, sorts the partitions concurrently and writes them in order into
+// a single file. The output is identical to the output of a Builder with
+// the same entries, appended in the same order.
+//
+// Append is safe for concurrent use. Values of the same cell are merged in
+// the order they were appended, the output (and its content hash) is only
+// reproducible if all values of a cell are appended by the same goroutine.
+type PartitionedBuilder struct {
+	o     *PartitionedBuilderOptions
+	so    SorterOptions // per partition
+	shift uint
+
+	mu    []sync.Mutex
+	parts []*Sorter
+	buf   []byte
+}
+
+// NewPartitionedBuilder inits a new partitioned builder.
+func NewPartitionedBuilder(o *PartitionedBuilderOptions) *PartitionedBuilder {
+	o = o.norm()
+	n := 6 << (2 * o.Level)
+
+	so := o.SorterOptions
+	if so.BufferSize < 1 {
+		so.BufferSize = 64 << 20
+	}
+	so.BufferSize /= n
+
+	return &PartitionedBuilder{
+		o:     o,
+		so:    so,
+		shift: uint(61 - 2*o.Level),
+		mu:    make([]sync.Mutex, n),
+		parts: make([]*Sorter, n),
+	}
+}
+
+// Append appends a cell to the builder.
+func (b *PartitionedBuilder) Append(cellID s2.CellID, data []byte) error {
+	if !cellID.IsValid() {
+		return errInvalidCellID
+	}
+
+	i := int(uint64(cellID) >> b.shift)
+	b.mu[i].Lock()
+	defer b.mu[i].Unlock()
+
+	if b.parts[i] == nil {
+		b.parts[i] = NewSorter(&b.so)
+	}
+	return b.parts[i].Append(cellID, data)
+}
+
+// Build sorts all partitions and writes the entries to w. Partitions
+// cover disjoint, ordered ranges of cell IDs and are written one after
+// the other. Please note that the writer is not closed.
+func (b *PartitionedBuilder) Build(w *Writer) error {
+	iters, err := b.sort()
+	for _, iter := range iters {
+		if iter != nil {
+			defer iter.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, iter := range iters {
+		if iter == nil {
+			continue
+		}
+
+		for {
+			cellID, values, err := iter.NextEntry()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+
+			b.buf = b.o.Merge(b.buf[:0], values)
+			if err := w.Append(uint64(cellID), b.buf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the builder and releases all resources.
+func (b *PartitionedBuilder) Close() error {
+	var err error
+	for _, s := range b.parts {
+		if s == nil {
+			continue
+		}
+		if e := s.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// sort sorts all partitions concurrently.
+func (b *PartitionedBuilder) sort() ([]*SorterIterator, error) {
+	var (
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, b.o.Concurrency)
+		iters = make([]*SorterIterator, len(b.parts))
+		errs  = make([]error, len(b.parts))
+	)
+	for i, s := range b.parts {
+		if s == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, s *Sorter) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			iters[i], errs[i] = s.Sort()
+		}(i, s)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return iters, err
+		}
+	}
+	return iters, nil
+}
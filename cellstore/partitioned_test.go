@@ -0,0 +1,143 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("PartitionedBuilder", func() {
+	type entry struct {
+		CellID s2.CellID
+		Value  []byte
+	}
+
+	var entries []entry
+
+	BeforeEach(func() {
+		rnd := rand.New(rand.NewSource(33))
+		entries = entries[:0]
+		for i := 0; i < 20000; i++ {
+			ll := s2.LatLngFromDegrees(rnd.Float64()*180-90, rnd.Float64()*360-180)
+			cellID := s2.CellIDFromLatLng(ll).Parent(8 + rnd.Intn(23))
+			entries = append(entries, entry{CellID: cellID, Value: []byte(fmt.Sprintf("value-%d", i))})
+			if i%10 == 0 {
+				entries = append(entries, entry{CellID: cellID, Value: []byte(fmt.Sprintf("dup-%d", i))})
+			}
+		}
+	})
+
+	build := func(b interface {
+		Append(s2.CellID, []byte) error
+		Build(*cellstore.Writer) error
+	}) []byte {
+		for _, ent := range entries {
+			Expect(b.Append(ent.CellID, ent.Value)).To(Succeed())
+		}
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{ContentHash: true})
+		Expect(b.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	It("should produce the same output as Builder", func() {
+		opt := cellstore.BuilderOptions{
+			SorterOptions: cellstore.SorterOptions{BufferSize: 64 * 1024},
+			Merge:         cellstore.JoinValues([]byte(",")),
+		}
+
+		b := cellstore.NewBuilder(&opt)
+		defer b.Close()
+		expected := build(b)
+
+		for _, level := range []int{0, 2} {
+			pb := cellstore.NewPartitionedBuilder(&cellstore.PartitionedBuilderOptions{
+				BuilderOptions: opt,
+				Level:          level,
+				Concurrency:    4,
+			})
+			defer pb.Close()
+			Expect(build(pb)).To(Equal(expected), "level %d", level)
+		}
+	})
+
+	It("should accept concurrent appends", func() {
+		pb := cellstore.NewPartitionedBuilder(nil)
+		defer pb.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				for j := i; j < 8*1000; j += 4 * 8 {
+					Expect(pb.Append(seedCellID+s2.CellID(j*8), []byte("x"))).To(Succeed())
+				}
+			}(i * 8)
+		}
+		wg.Wait()
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, nil)
+		Expect(pb.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+
+		var n int
+		iter := r.All()
+		defer iter.Release()
+		for iter.Next() {
+			n++
+		}
+		Expect(n).To(Equal(1000))
+	})
+
+	It("should be reproducible if each cell is appended by a single goroutine", func() {
+		b := cellstore.NewBuilder(nil)
+		defer b.Close()
+		expected := build(b)
+
+		pb := cellstore.NewPartitionedBuilder(nil)
+		defer pb.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				for _, ent := range entries {
+					if int(ent.CellID>>32)%4 == i {
+						Expect(pb.Append(ent.CellID, ent.Value)).To(Succeed())
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{ContentHash: true})
+		Expect(pb.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(buf.Bytes()).To(Equal(expected))
+	})
+
+	It("should reject invalid cell IDs", func() {
+		pb := cellstore.NewPartitionedBuilder(nil)
+		defer pb.Close()
+		Expect(pb.Append(seedCellID+1, []byte("data1"))).To(MatchError(`cellstore: invalid cell ID`))
+	})
+})
@@ -0,0 +1,7 @@
+//go:build race
+
+package cellstore_test
+
+// raceEnabled is set when built with the race detector, which
+// randomly drops sync.Pool items.
+const raceEnabled = true
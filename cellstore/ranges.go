@@ -0,0 +1,133 @@
+package cellstore
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+// RangeWriter writes cell coverings as [RangeMin, RangeMax] intervals of
+// leaf cells, instead of individual entries. Stores written by a RangeWriter
+// must be queried via Reader.Lookup.
+//
+// Each interval is stored under its RangeMax key, followed by its length
+// and the value. Intervals must be appended in order and must not overlap.
+type RangeWriter struct {
+	w    *Writer
+	last s2.CellID
+	buf  []byte
+}
+
+// NewRangeWriter wraps a writer and returns a RangeWriter.
+func NewRangeWriter(w io.Writer, o *sntable.WriterOptions) *RangeWriter {
+	var wo WriterOptions
+	if o != nil {
+		wo.WriterOptions = *o
+	}
+	return &RangeWriter{w: NewWriterWithOptions(w, &wo)}
+}
+
+// Append appends a single cell with a value.
+func (w *RangeWriter) Append(cellID s2.CellID, value []byte) error {
+	if !cellID.IsValid() {
+		return errInvalidCellID
+	}
+	return w.AppendRange(cellID.RangeMin(), cellID.RangeMax(), value)
+}
+
+// AppendCellUnion normalises cu and appends it with a value. Adjacent cells
+// are merged into a single interval.
+func (w *RangeWriter) AppendCellUnion(cu s2.CellUnion, value []byte) error {
+	cu = append(s2.CellUnion(nil), cu...)
+	for _, cellID := range cu {
+		if !cellID.IsValid() {
+			return errInvalidCellID
+		}
+	}
+	cu.Normalize()
+
+	for _, rng := range coverRanges(cu) {
+		if err := w.AppendRange(rng[0], rng[1], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendRange appends an interval between two leaf cells (both inclusive)
+// with a value.
+func (w *RangeWriter) AppendRange(min, max s2.CellID, value []byte) error {
+	if !min.IsValid() || !min.IsLeaf() || !max.IsValid() || !max.IsLeaf() || max < min {
+		return errInvalidRange
+	}
+	if w.last != 0 && min <= w.last {
+		return errOverlappingRanges
+	}
+
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(max-min))
+	w.buf = append(w.buf, value...)
+	if err := w.w.Append(uint64(max), w.buf); err != nil {
+		return err
+	}
+	w.last = max
+	return nil
+}
+
+// Close closes the writer.
+func (w *RangeWriter) Close() error {
+	return w.w.Close()
+}
+
+// --------------------------------------------------------------------
+
+// Lookup finds the interval containing cellID in a store written by a
+// RangeWriter and returns its value. It returns ErrNotFound if cellID is
+// not fully covered by a stored interval.
+func (r *Reader) Lookup(cellID s2.CellID) ([]byte, error) {
+	return r.AppendLookup(nil, cellID)
+}
+
+// AppendLookup is like Lookup but appends the value to dst.
+func (r *Reader) AppendLookup(dst []byte, cellID s2.CellID) ([]byte, error) {
+	if !cellID.IsValid() {
+		return dst, errInvalidCellID
+	}
+
+	qs := r.newQueryStats("Lookup")
+	defer r.observe(qs)
+
+	// the first interval ending at or after the query is the only candidate
+	min, max := cellID.RangeMin(), cellID.RangeMax()
+
+	var iter SectionIterator
+	if err := r.seekSection(&iter, uint64(max), qs); err != nil {
+		return dst, err
+	}
+	defer iter.Release()
+
+	iter.s.Seek(uint64(max))
+	for !iter.Next() {
+		if !iter.NextSection() {
+			if err := iter.Err(); err != nil {
+				return dst, err
+			}
+			return dst, ErrNotFound
+		}
+	}
+
+	value := iter.Value()
+	size, n := binary.Uvarint(value)
+	if n < 1 {
+		return dst, errBadRange
+	}
+	if s2.CellID(uint64(iter.CellID())-size) > min {
+		return dst, ErrNotFound
+	}
+
+	if qs != nil {
+		qs.EntriesReturned++
+	}
+	return append(dst, value[n:]...), nil
+}
@@ -0,0 +1,121 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("RangeWriter", func() {
+	london := cellIDFromDegrees(51.5, -0.12)
+	paris := cellIDFromDegrees(48.86, 2.35)
+
+	cover := func(cellID s2.CellID, meters float64) s2.CellUnion {
+		rc := &s2.RegionCoverer{MaxLevel: 16, MaxCells: 64}
+		return rc.Covering(cellstore.DefaultEarth.Cap(cellID.Point(), meters))
+	}
+
+	open := func(buf *bytes.Buffer) *cellstore.Reader {
+		r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	numEntries := func(r *cellstore.Reader) int {
+		it, err := r.Seek(0)
+		Expect(err).NotTo(HaveOccurred())
+		defer it.Release()
+
+		n := 0
+		for it.Next() {
+			n++
+		}
+		Expect(it.Err()).NotTo(HaveOccurred())
+		return n
+	}
+
+	It("should write and look up cell unions", func() {
+		cu := cover(london, 20e3)
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewRangeWriter(buf, &sntable.WriterOptions{BlockSize: 256})
+		Expect(w.AppendCellUnion(cu, []byte("London"))).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		r := open(buf)
+		Expect(numEntries(r)).To(BeNumerically("<", len(cu)))
+
+		Expect(r.Lookup(london)).To(Equal([]byte("London")))
+		Expect(r.Lookup(london.Parent(12))).To(Equal([]byte("London")))
+		Expect(r.Lookup(cellIDFromDegrees(51.6, -0.12))).To(Equal([]byte("London")))
+		Expect(r.AppendLookup([]byte("in "), london)).To(Equal([]byte("in London")))
+
+		_, err := r.Lookup(paris)
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+		_, err = r.Lookup(london.Parent(5))
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+		_, err = r.Lookup(s2.CellIDFromFace(5).ChildEnd().Prev())
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+	})
+
+	It("should reject overlapping and invalid ranges", func() {
+		w := cellstore.NewRangeWriter(new(bytes.Buffer), nil)
+		Expect(w.Append(london.Parent(10), []byte("a"))).To(Succeed())
+		Expect(w.Append(london.Parent(12), []byte("b"))).To(MatchError(`cellstore: ranges overlap or are out of order`))
+		Expect(w.Append(s2.CellIDFromFace(0), []byte("c"))).To(MatchError(`cellstore: ranges overlap or are out of order`))
+		Expect(w.AppendRange(paris.Parent(10), paris, nil)).To(MatchError(`cellstore: invalid range`))
+		Expect(w.AppendRange(paris.Next(), paris, nil)).To(MatchError(`cellstore: invalid range`))
+		Expect(w.Append(1317624576600000002, nil)).To(MatchError(`cellstore: invalid cell ID`))
+		Expect(w.Close()).To(Succeed())
+	})
+
+	It("should build from multiple unions", func() {
+		b := cellstore.NewBuilder(nil)
+		defer b.Close()
+
+		Expect(b.AppendCellUnion(cover(paris, 10e3), []byte("Paris"))).To(Succeed())
+		Expect(b.AppendCellUnion(cover(london, 20e3), []byte("London"))).To(Succeed())
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewRangeWriter(buf, nil)
+		Expect(b.BuildRanges(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		r := open(buf)
+		Expect(r.Lookup(london)).To(Equal([]byte("London")))
+		Expect(r.Lookup(paris)).To(Equal([]byte("Paris")))
+		Expect(r.Lookup(cellIDFromDegrees(48.9, 2.3))).To(Equal([]byte("Paris")))
+
+		_, err := r.Lookup(cellIDFromDegrees(50, 1))
+		Expect(err).To(MatchError(cellstore.ErrNotFound))
+	})
+
+	It("should reject unions which overlap at different levels", func() {
+		b := cellstore.NewBuilder(nil)
+		defer b.Close()
+
+		Expect(b.AppendCellUnion(s2.CellUnion{london.Parent(10)}, []byte("city"))).To(Succeed())
+		Expect(b.AppendCellUnion(s2.CellUnion{london.Parent(12)}, []byte("district"))).To(Succeed())
+
+		w := cellstore.NewRangeWriter(new(bytes.Buffer), nil)
+		Expect(b.BuildRanges(w)).To(MatchError(`cellstore: ranges overlap or are out of order`))
+	})
+
+	It("should merge equal cells of different unions", func() {
+		b := cellstore.NewBuilder(&cellstore.BuilderOptions{Merge: cellstore.JoinValues([]byte(","))})
+		defer b.Close()
+
+		Expect(b.AppendCellUnion(s2.CellUnion{london.Parent(10)}, []byte("a"))).To(Succeed())
+		Expect(b.AppendCellUnion(s2.CellUnion{london.Parent(10)}, []byte("b"))).To(Succeed())
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewRangeWriter(buf, nil)
+		Expect(b.BuildRanges(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(open(buf).Lookup(london)).To(Equal([]byte("a,b")))
+	})
+})
@@ -1,24 +1,254 @@
 package cellstore
 
 import (
+	"context"
 	"io"
+	"sort"
+	"sync"
 
 	"github.com/bsm/sntable"
+	"github.com/golang/geo/s1"
 	"github.com/golang/geo/s2"
 )
 
+// ReaderOptions define Reader specific options.
+type ReaderOptions struct {
+	// Observer is an optional observer which is notified
+	// about the statistics of each query.
+	Observer Observer
+
+	// Earth is the model used to convert metric distances of queries.
+	// Default: DefaultEarth
+	Earth Earth
+}
+
+func (o *ReaderOptions) norm() *ReaderOptions {
+	var oo ReaderOptions
+	if o != nil {
+		oo = *o
+	}
+	return &oo
+}
+
 // Reader represents a cellstore reader
 type Reader struct {
 	*sntable.Reader
+
+	obs    Observer
+	blocks *blockStats
+	earth  Earth
+
+	index   *tableIndex   // only set when filters are present
+	filters []bloomFilter // block filters
+	meta    meta          // file metadata
+
+	src          io.ReaderAt         // underlying file
+	opts         *ReaderOptions      // options, inherited by layers
+	layers       map[string][2]int64 // layer offsets and sizes
+	layerMu      sync.Mutex
+	layerReaders map[string]*Reader
+
+	closer io.Closer // only set by Open* helpers
 }
 
 // NewReader opens a reader.
 func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	return NewReaderWithOptions(r, size, nil)
+}
+
+// NewReaderWithOptions opens a reader with custom options.
+func NewReaderWithOptions(r io.ReaderAt, size int64, o *ReaderOptions) (*Reader, error) {
+	o = o.norm()
+	src := r
+
+	size, m, err := readTrailer(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers map[string][2]int64
+	if p, ok := m[metaLayers]; ok {
+		if layers, err = parseLayers(p); err != nil {
+			return nil, err
+		}
+	}
+
+	var index *tableIndex
+	if o.Observer != nil || m[metaFilters] != nil {
+		if index, err = readTableIndex(r, size); err != nil {
+			return nil, err
+		}
+	}
+
+	var filters []bloomFilter
+	if p, ok := m[metaFilters]; ok {
+		if filters, err = parseFilters(p, index.NumBlocks()); err != nil {
+			return nil, err
+		}
+	}
+
+	var blocks *blockStats
+	if o.Observer != nil {
+		blocks = newBlockStats(r, index)
+		r = blocks
+	}
+
 	tr, err := sntable.NewReader(r, size)
 	if err != nil {
 		return nil, err
 	}
-	return &Reader{Reader: tr}, nil
+
+	rd := &Reader{
+		Reader: tr,
+		obs:    o.Observer,
+		blocks: blocks,
+		earth:  o.Earth.or(DefaultEarth),
+		meta:   m,
+		src:    src,
+		opts:   o,
+		layers: layers,
+	}
+	if filters != nil {
+		rd.index, rd.filters = index, filters
+	}
+	return rd, nil
+}
+
+// Close releases resources held by readers which were opened by helpers,
+// such as OpenURL. It is a no-op for readers created via NewReader.
+func (r *Reader) Close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// ContentHash returns the SHA-256 hash of all keys and values, as recorded
+// by writers with the ContentHash option. It returns nil if no hash was
+// recorded. Layers are not covered, each layer records its own hash.
+func (r *Reader) ContentHash() []byte {
+	return r.meta[metaContentHash]
+}
+
+// Get returns the value of a single key.
+// It may return an ErrNotFound error.
+func (r *Reader) Get(key uint64) ([]byte, error) {
+	return r.Append(nil, key)
+}
+
+// Append retrieves the value of a single key and appends it to dst.
+// Blocks are skipped without reading if their filter rules out the key.
+// It may return an ErrNotFound error.
+func (r *Reader) Append(dst []byte, key uint64) ([]byte, error) {
+	qs := r.newQueryStats("Get")
+	defer r.observe(qs)
+
+	found, err := r.lookupKey(key, qs, func(value []byte) {
+		dst = append(dst, value...)
+	})
+	if err != nil {
+		return dst, err
+	} else if !found {
+		return dst, ErrNotFound
+	}
+	if qs != nil {
+		qs.EntriesReturned++
+	}
+	return dst, nil
+}
+
+// lookupKey calls fn with the value of an exact key, if found.
+func (r *Reader) lookupKey(key uint64, qs *QueryStats, fn func([]byte)) (bool, error) {
+	if !r.mayContain(key, qs) {
+		return false, nil
+	}
+
+	var iter SectionIterator
+	if err := r.seekSection(&iter, key, qs); err != nil {
+		return false, err
+	}
+	defer iter.Release()
+
+	iter.s.Seek(key)
+	if !iter.Next() || uint64(iter.CellID()) != key {
+		if r.filters != nil && qs != nil {
+			qs.FilterFalsePositives++
+		}
+		return false, nil
+	}
+
+	fn(iter.Value())
+	return true, nil
+}
+
+// lookupKeys calls fn for each of the ascending keys which is stored. The
+// table is traversed in a single forward pass, blocks are only fetched
+// once.
+func (r *Reader) lookupKeys(ctx context.Context, keys []s2.CellID, qs *QueryStats, fn func(s2.CellID, []byte) error) error {
+	var iter SectionIterator
+	var seeked bool
+	defer func() {
+		if seeked {
+			iter.Release()
+		}
+	}()
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !r.mayContain(uint64(key), qs) {
+			continue
+		}
+
+		// keys beyond the current block require a seek
+		if seeked && iter.b.Pos() >= r.NumBlocks() {
+			return nil
+		} else if seeked && !iter.seekBlockKey(uint64(key)) {
+			iter.Release()
+			seeked = false
+		}
+		if !seeked {
+			if err := r.seekSection(&iter, uint64(key), qs); err != nil {
+				return err
+			}
+			seeked = true
+		}
+
+		iter.s.Seek(uint64(key))
+		if !iter.Next() || iter.CellID() != key {
+			if r.filters != nil && qs != nil {
+				qs.FilterFalsePositives++
+			}
+			continue
+		}
+		if err := fn(key, iter.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mayContain checks block filters, if present, and returns false if
+// the key is definitely not stored.
+func (r *Reader) mayContain(key uint64, qs *QueryStats) bool {
+	if r.filters == nil {
+		return true
+	}
+
+	bpos := r.index.Search(key)
+	if bpos == len(r.filters) {
+		return false
+	}
+
+	ok := r.filters[bpos].MayContain(key)
+	if qs != nil {
+		qs.FilterChecks++
+		if !ok {
+			qs.FilterNegatives++
+		}
+	}
+	return ok
 }
 
 // FindSection finds a section right before the the cellID.
@@ -27,43 +257,214 @@ func (r *Reader) FindSection(cellID s2.CellID) (*SectionIterator, error) {
 		return nil, errInvalidCellID
 	}
 
-	key := uint64(cellID)
-	b, err := r.SeekBlock(key)
+	iter, err := r.findSection(uint64(cellID), r.newQueryStats("FindSection"))
 	if err != nil {
 		return nil, err
 	}
+	iter.observe = true
+	return iter, nil
+}
+
+func (r *Reader) findSection(key uint64, qs *QueryStats) (*SectionIterator, error) {
+	iter := new(SectionIterator)
+	if err := r.seekSection(iter, key, qs); err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// seekSection positions iter at the section right before key.
+func (r *Reader) seekSection(iter *SectionIterator, key uint64, qs *QueryStats) error {
+	b, err := r.SeekBlock(key)
+	if err != nil {
+		return err
+	}
+	r.observeBlock(qs, b)
 
 	s := b.SeekSection(key)
-	return &SectionIterator{r: r, b: b, s: s, bpos: b.Pos(), spos: s.Pos()}, nil
+	if qs != nil {
+		qs.SectionsScanned++
+	}
+	*iter = SectionIterator{r: r, b: b, s: s, bpos: b.Pos(), spos: s.Pos(), stats: qs}
+	return nil
+}
+
+// newQueryStats returns new query stats, but only if the reader is observed.
+func (r *Reader) newQueryStats(query string) *QueryStats {
+	if r.obs == nil {
+		return nil
+	}
+	return &QueryStats{Query: query}
 }
 
-// Nearby returns a limited iterator over close to cellID.
-// Please note that the iterator entries are not sorted.
+func (r *Reader) observeBlock(qs *QueryStats, b *sntable.BlockReader) {
+	if qs != nil && r.blocks != nil {
+		r.blocks.Observe(qs, b.Pos())
+	}
+}
+
+func (r *Reader) observe(qs *QueryStats) {
+	if qs != nil {
+		r.obs.ObserveQuery(qs)
+	}
+}
+
+// Nearby returns a limited result set of entries close to cellID, sorted by distance.
+// Entries stored at levels below 30 are areas, their distance is measured to
+// the closest point of the cell.
 func (r *Reader) Nearby(cellID s2.CellID, limit int) (*NearbyRS, error) {
-	iter, err := r.FindSection(cellID)
-	if err != nil {
+	return r.NearbyWithOptions(cellID, limit, nil)
+}
+
+// NearbyWithOptions returns a limited result set of entries close to
+// cellID, sorted by distance and restricted by the given options.
+func (r *Reader) NearbyWithOptions(cellID s2.CellID, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	return r.NearbyContext(context.Background(), cellID, limit, o)
+}
+
+// NearbyContext is like NearbyWithOptions but aborts the search with
+// ctx.Err() once the context is cancelled.
+func (r *Reader) NearbyContext(ctx context.Context, cellID s2.CellID, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	rs := newNearbyRS()
+	if err := r.NearbyInto(ctx, rs, cellID, limit, o); err != nil {
+		rs.Release()
 		return nil, err
 	}
+	return rs, nil
+}
+
+// NearbyInto is like NearbyContext but populates a caller-owned result set.
+// The set is reset first, its entries and value buffers are reused, which
+// makes repeated queries allocation-free in a steady state.
+func (r *Reader) NearbyInto(ctx context.Context, rs *NearbyRS, cellID s2.CellID, limit int, o *NearbyOptions) error {
+	return r.nearbyInto(ctx, rs, cellID, cellID.Point(), limit, o)
+}
+
+// NearbyPoint returns a limited result set of entries close to p, sorted by
+// distance. Unlike Nearby, distances are measured from the exact point
+// rather than from the centre of its leaf cell.
+func (r *Reader) NearbyPoint(p s2.Point, limit int) (*NearbyRS, error) {
+	return r.NearbyPointContext(context.Background(), p, limit, nil)
+}
+
+// NearbyLatLng is like NearbyPoint but accepts a coordinate.
+func (r *Reader) NearbyLatLng(ll s2.LatLng, limit int) (*NearbyRS, error) {
+	return r.NearbyPoint(s2.PointFromLatLng(ll), limit)
+}
+
+// NearbyPointContext is like NearbyPoint but accepts options and aborts the
+// search with ctx.Err() once the context is cancelled.
+func (r *Reader) NearbyPointContext(ctx context.Context, p s2.Point, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	rs := newNearbyRS()
+	if err := r.nearbyInto(ctx, rs, s2.CellFromPoint(p).ID(), p, limit, o); err != nil {
+		rs.Release()
+		return nil, err
+	}
+	return rs, nil
+}
+
+// nearbyInto seeks entries around the leaf cellID, measuring distances from origin.
+func (r *Reader) nearbyInto(ctx context.Context, rs *NearbyRS, cellID s2.CellID, origin s2.Point, limit int, o *NearbyOptions) error {
+	o = o.norm()
+	rs.Reset()
+
+	qs := r.newQueryStats("Nearby")
+	defer r.observe(qs)
+
+	if !cellID.IsValid() {
+		return errInvalidCellID
+	}
+
+	rs.Earth = o.Earth.or(r.earth)
+	maxDist := o.maxAngle(rs.Earth)
+
+	// resume paginated queries from the frontiers of the previous page
+	if len(o.Token) != 0 {
+		if o.GroupBy != nil {
+			return errGroupedToken
+		}
+
+		t, err := parseNearbyToken(o.Token)
+		if err != nil {
+			return err
+		} else if t.Pivot != cellID {
+			return errInvalidToken
+		}
+		return r.resumeNearby(ctx, rs, t, origin, limit, maxDist, qs)
+	}
+
+	var iter SectionIterator
+	if err := r.seekSection(&iter, uint64(cellID), qs); err != nil {
+		return err
+	}
 	defer iter.Release()
 
 	numEntries := limit + 12
-	rs := newNearbyRS()
-	origin := cellID.Point()
 
-	// count number of records left and right of pivot
+	// count number of records left and right of pivot,
+	// track if there are more records beyond the window
 	var nleft, nright int
+	var more bool
+
+	// group entries by key, if requested
+	var groups map[string]*NearbyEntry
+	if o.GroupBy != nil {
+		groups = make(map[string]*NearbyEntry)
+	}
+
+	// consider ranks an entry, or merges it into its group
+	consider := func(cID s2.CellID, value []byte, maxDist s1.Angle) {
+		dist := cellDistance(cID, origin)
+		if dist > maxDist {
+			return
+		}
+
+		if groups != nil {
+			key := o.GroupBy(value)
+			if g, ok := groups[string(key)]; ok {
+				g.Count++
+				if dist < g.Distance || (dist == g.Distance && cID < g.CellID) {
+					g.CellID, g.Distance = cID, dist
+					g.Value = append(g.Value[:0], value...)
+				}
+				return
+			}
+			groups[string(key)] = &NearbyEntry{CellID: cID, Value: append([]byte(nil), value...), Distance: dist, Count: 1}
+			return
+		}
+
+		if !rs.push(&NearbyEntry{CellID: cID, Value: value, Distance: dist}, limit) {
+			more = true
+		}
+	}
+
+	// track the scanned window, all entries count towards it
+	left, right := cellID, cellID
+	add := func(cID s2.CellID, value []byte) {
+		if cID < left {
+			left = cID
+		} else if cID > right {
+			right = cID
+		}
+		consider(cID, value, maxDist)
+	}
 
 ForwardLoop:
 	for {
 		for iter.Next() {
 			cID := iter.CellID()
-			rs.add(cID, iter.Value(), cID.Point().Distance(origin))
+			add(cID, iter.Value())
+
 			if cID < cellID {
 				nleft++
 			} else if nright++; nright >= numEntries {
+				more = true
 				break ForwardLoop
 			}
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if !iter.NextSection() {
 			break
 		}
@@ -71,25 +472,255 @@ ForwardLoop:
 
 	iter.Reset()
 
-ReverseLoop:
+	// sections are read in key order, finish each section to
+	// consider all entries between the left edge and the pivot
 	for iter.PrevSection() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		for iter.Next() {
 			cID := iter.CellID()
-			rs.add(cID, iter.Value(), cID.Point().Distance(origin))
+			add(cID, iter.Value())
+
 			if cID >= cellID {
 				nright++
-			} else if nleft++; nleft >= numEntries {
-				break ReverseLoop
+			} else {
+				nleft++
 			}
 		}
+		if nleft >= numEntries {
+			more = true
+			break
+		}
 	}
 	if err := iter.Err(); err != nil {
-		return nil, err
+		return err
+	}
+
+	// scan neighbouring cells within the radius of the current
+	// candidates, to find close entries which are far away in
+	// the Hilbert curve order, e.g. across face boundaries
+	covered := s1.Angle(-1) // all entries within were considered
+	if o.Neighbors {
+		radius := rs.maxDistance(limit)
+		if groups != nil {
+			radius = groupRadius(groups, limit)
+		}
+		if maxDist < radius {
+			radius = maxDist
+		}
+		if maxNeighborRadius < radius {
+			radius = maxNeighborRadius // also applies if there are too few candidates
+		}
+
+		if err := r.scanNeighbors(ctx, cellID, radius, left, right, qs, func(cID s2.CellID, value []byte) error {
+			consider(cID, value, radius)
+			return nil
+		}); err != nil {
+			return err
+		}
+		covered = radius
+	}
+
+	// grouped queries are not paginated
+	if groups != nil {
+		for _, g := range groups {
+			rs.push(g, limit)
+		}
+		more = false
 	}
 
 	rs.sort()
-	rs.limit(limit)
-	return rs, nil
+	rs.calcBearings(s2.LatLngFromPoint(origin))
+
+	if n := rs.Len(); n != 0 && more {
+		// all entries up to the last one are known to be returned
+		// if the neighbour scan covered its distance
+		last := rs.Entries[n-1]
+		if last.Distance <= covered {
+			left, right = 0, ^s2.CellID(0)
+		}
+
+		next := nearbyToken{Pivot: cellID, Last: last.CellID, Distance: last.Distance, Min: left, Max: right, Left: cellID, Right: cellID - 1}
+		rs.Token = next.AppendTo(rs.Token[:0])
+	}
+	if qs != nil {
+		qs.EntriesReturned = rs.Len()
+	}
+	return nil
+}
+
+// resumeNearby returns the next page of a paginated nearby query. Entries
+// are consumed outwards from the frontiers of the previous page, merging
+// both directions by distance. Neighbouring cells are not scanned.
+func (r *Reader) resumeNearby(ctx context.Context, rs *NearbyRS, t *nearbyToken, origin s2.Point, limit int, maxDist s1.Angle, qs *QueryStats) error {
+	numEntries := limit + 12
+
+	// collect candidates on either side, in order of consumption;
+	// skipped entries are out of range or were returned already
+	type candidate struct {
+		NearbyEntry
+		skip bool
+	}
+	var lhs, rhs []candidate
+	collect := func(dst []candidate, cID s2.CellID, value []byte) []candidate {
+		if qs != nil {
+			qs.EntriesConsidered++
+		}
+
+		dist := cellDistance(cID, origin)
+		skip := dist > maxDist || t.Returned(cID, dist)
+		if !skip {
+			value = append([]byte(nil), value...)
+		}
+		return append(dst, candidate{NearbyEntry: NearbyEntry{CellID: cID, Value: value, Distance: dist}, skip: skip})
+	}
+
+	// scan left of the consumed range, in reverse
+	var lmore, rmore bool
+	if t.Left > 0 {
+		iter := r.reverse(t.Left-1, qs)
+		for iter.Next() {
+			if lhs = collect(lhs, iter.CellID(), iter.Value()); len(lhs) == numEntries {
+				lmore = true
+				break
+			}
+		}
+		err := iter.Err()
+		iter.Release()
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	// scan right of the consumed range
+	if err := r.scanRange(ctx, t.Right+1, ^s2.CellID(0), qs, func(cID s2.CellID, value []byte) error {
+		if rhs = collect(rhs, cID, value); len(rhs) == numEntries {
+			rmore = true
+			return errStopScan
+		}
+		return nil
+	}); err != nil && err != errStopScan {
+		return err
+	}
+
+	// merge both sides by distance, advance the frontiers
+	left, right := t.Left, t.Right
+	var i, j int
+	for rs.Len() < limit {
+		for ; i < len(lhs) && lhs[i].skip; i++ {
+			left = lhs[i].CellID
+		}
+		for ; j < len(rhs) && rhs[j].skip; j++ {
+			right = rhs[j].CellID
+		}
+
+		if (i == len(lhs) && lmore) || (j == len(rhs) && rmore) {
+			break // cannot compare against unscanned entries
+		} else if i < len(lhs) && (j == len(rhs) || rs.rankLess(&lhs[i].NearbyEntry, &rhs[j].NearbyEntry)) {
+			rs.push(&lhs[i].NearbyEntry, limit)
+			left = lhs[i].CellID
+			i++
+		} else if j < len(rhs) {
+			rs.push(&rhs[j].NearbyEntry, limit)
+			right = rhs[j].CellID
+			j++
+		} else {
+			break
+		}
+	}
+
+	rs.sort()
+	rs.calcBearings(s2.LatLngFromPoint(origin))
+
+	if rs.Len() != 0 && (lmore || rmore || i < len(lhs) || j < len(rhs)) {
+		next := *t
+		next.Left, next.Right = left, right
+		rs.Token = next.AppendTo(rs.Token[:0])
+	}
+	if qs != nil {
+		qs.EntriesReturned = rs.Len()
+	}
+	return nil
+}
+
+// groupRadius returns the distance of the limit-th closest group.
+func groupRadius(groups map[string]*NearbyEntry, limit int) s1.Angle {
+	if limit < 1 || len(groups) < limit {
+		return s1.InfAngle()
+	}
+
+	dists := make([]float64, 0, len(groups))
+	for _, g := range groups {
+		dists = append(dists, float64(g.Distance))
+	}
+	sort.Float64s(dists)
+	return s1.Angle(dists[limit-1])
+}
+
+// minNeighborLevel is the level of the largest cells scanned for
+// neighbours, cells are at least ~94km wide.
+const minNeighborLevel = 6
+
+// maxNeighborRadius is the largest radius covered by neighbour scans.
+var maxNeighborRadius = s1.Angle(s2.MinWidthMetric.Value(minNeighborLevel))
+
+// scanNeighbors calls fn for each entry in the cell containing cellID and
+// its neighbours, at a level where cells are at least as wide as radius,
+// but no lower than minNeighborLevel. Entries within the already scanned
+// window between left and right are skipped.
+func (r *Reader) scanNeighbors(ctx context.Context, cellID s2.CellID, radius s1.Angle, left, right s2.CellID, qs *QueryStats, fn func(s2.CellID, []byte) error) error {
+	level := s2.MinWidthMetric.MaxLevel(radius.Radians())
+	if level < minNeighborLevel {
+		level = minNeighborLevel
+	}
+	if lvl := cellID.Level(); level > lvl {
+		level = lvl
+	}
+
+	cell := cellID.Parent(level)
+	cu := s2.CellUnion(append(cell.AllNeighbors(level), cell))
+	cu.Normalize()
+
+	for _, rng := range coverRanges(cu) {
+		if min, max := rng[0], rng[1]; min < left {
+			if max >= left {
+				max = left - 1
+			}
+			if err := r.scanRange(ctx, min, max, qs, fn); err != nil {
+				return err
+			}
+		}
+		if min, max := rng[0], rng[1]; max > right {
+			if min <= right {
+				min = right + 1
+			}
+			if err := r.scanRange(ctx, min, max, qs, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ResumeSection resumes iteration after the entry the token was
+// generated from using SectionIterator.Token.
+func (r *Reader) ResumeSection(token Token) (*SectionIterator, error) {
+	last, err := parseCellIDToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := r.FindSection(last)
+	if err != nil {
+		return nil, err
+	}
+	iter.s.Seek(uint64(last) + 1)
+	return iter, nil
 }
 
 // --------------------------------------------------------------------
@@ -103,18 +734,40 @@ type SectionIterator struct {
 	bpos int // original block position
 	spos int // original section position
 	err  error
+
+	stats   *QueryStats // only set when observed
+	observe bool        // report stats on release
+	release func()      // called on release, if set
 }
 
 // Release releases the iterator to the pool.
 func (i *SectionIterator) Release() {
-	i.b.Release()
+	if i.err == errReleased {
+		return
+	}
+	if i.b != nil {
+		i.b.Release()
+	}
 	i.s.Release()
 	i.err = errReleased
+
+	if i.observe && i.stats != nil {
+		i.stats.EntriesReturned = i.stats.EntriesConsidered
+		i.r.observe(i.stats)
+	}
+	if fn := i.release; fn != nil {
+		i.release = nil
+		fn()
+	}
 }
 
 // Err exposes errors.
 func (i *SectionIterator) Err() error { return i.err }
 
+// Token returns a continuation token which can be passed to
+// Reader.ResumeSection to resume iteration after the current entry.
+func (i *SectionIterator) Token() Token { return appendCellIDToken(nil, i.CellID()) }
+
 // CellID returns the CellID of the current entry.
 func (i *SectionIterator) CellID() s2.CellID { return s2.CellID(i.s.Key()) }
 
@@ -122,7 +775,15 @@ func (i *SectionIterator) CellID() s2.CellID { return s2.CellID(i.s.Key()) }
 func (i *SectionIterator) Value() []byte { return i.s.Value() }
 
 // Next advances the cursor to the next entry in the section.
-func (i *SectionIterator) Next() bool { return i.s.Next() }
+func (i *SectionIterator) Next() bool {
+	if !i.s.Next() {
+		return false
+	}
+	if i.stats != nil {
+		i.stats.EntriesConsidered++
+	}
+	return true
+}
 
 // NextSection advances the iterator to the next section.
 func (i *SectionIterator) NextSection() bool {
@@ -149,6 +810,23 @@ func (i *SectionIterator) Reset() bool {
 	return i.moveTo(i.bpos, i.spos)
 }
 
+// seekBlockKey positions the cursor at the section of key within the
+// current block. It returns false if key is beyond the current block.
+func (i *SectionIterator) seekBlockKey(key uint64) bool {
+	s := i.b.SeekSection(key)
+	if s.Pos() >= i.b.NumSections() {
+		s.Release()
+		return false
+	}
+
+	i.s.Release()
+	i.s = s
+	if i.stats != nil {
+		i.stats.SectionsScanned++
+	}
+	return true
+}
+
 func (i *SectionIterator) moveTo(bpos, spos int) bool {
 	if i.err != nil {
 		return false
@@ -156,7 +834,9 @@ func (i *SectionIterator) moveTo(bpos, spos int) bool {
 
 	if i.b.Pos() != bpos {
 		i.b.Release()
-		i.b, i.err = i.r.GetBlock(bpos)
+		if i.b, i.err = i.r.GetBlock(bpos); i.err == nil {
+			i.r.observeBlock(i.stats, i.b)
+		}
 		return i.moveTo(bpos, spos)
 	}
 
@@ -167,6 +847,9 @@ func (i *SectionIterator) moveTo(bpos, spos int) bool {
 	if i.s.Pos() != spos {
 		i.s.Release()
 		i.s = i.b.GetSection(spos)
+		if i.stats != nil {
+			i.stats.SectionsScanned++
+		}
 	}
 	return true
 }
@@ -1,6 +1,8 @@
 package cellstore_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -120,6 +122,143 @@ var _ = Describe("Reader", func() {
 		))
 	})
 
+	It("should find nearby into caller-owned sets", func() {
+		rs := new(cellstore.NearbyRS)
+		Expect(subject.NearbyInto(context.Background(), rs, 1317624576600000301, 4, nil)).To(Succeed())
+		Expect(rs).To(ContainCells(
+			1317624576600000297,
+			1317624576600000305,
+			1317624576600000217,
+			1317624576600000289,
+		))
+		Expect(rs.Token).NotTo(BeEmpty())
+
+		exp, err := subject.Nearby(1317624576600000301, 4)
+		Expect(err).NotTo(HaveOccurred())
+		defer exp.Release()
+		Expect(rs.Entries).To(Equal(exp.Entries))
+
+		Expect(subject.NearbyInto(context.Background(), rs, 1317624576600000801, 2, nil)).To(Succeed())
+		Expect(rs).To(ContainCells(
+			1317624576600000737,
+			1317624576600000729,
+		))
+		Expect(rs.Entries[0].Value).To(HaveLen(128))
+	})
+
+	It("should find nearby into caller-owned sets without allocations", func() {
+		if raceEnabled {
+			Skip("allocations are not stable with the race detector")
+		}
+
+		data := seedBytes(100)
+		observer := new(blockObserver)
+		observed, err := cellstore.NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), &cellstore.ReaderOptions{Observer: observer})
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx := context.Background()
+		rs := new(cellstore.NearbyRS)
+		Expect(observed.NearbyInto(ctx, rs, 1317624576600000301, 4, nil)).To(Succeed())
+		blocks := observer.BlocksFetched
+		Expect(blocks).To(BeNumerically(">", 0))
+
+		// only the underlying table allocates, twice per fetched block,
+		// along with the stats of the observed query
+		allocs := testing.AllocsPerRun(10, func() {
+			_ = observed.NearbyInto(ctx, rs, 1317624576600000301, 4, nil)
+		})
+		Expect(observer.BlocksFetched).To(Equal(blocks))
+		Expect(allocs).To(BeNumerically("<=", 2*blocks+1))
+	})
+
+	It("should find nearby within a max distance", func() {
+		london := cellIDFromDegrees(51.5, -0.12)
+		paris := cellIDFromDegrees(48.86, 2.35)
+		berlin := cellIDFromDegrees(52.52, 13.4)
+		subject = seedStore(map[s2.CellID]string{london: "London", paris: "Paris", berlin: "Berlin"})
+
+		rs, err := subject.NearbyWithOptions(london, 10, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs).To(ContainCells(london, paris, berlin))
+
+		rs, err = subject.NearbyWithOptions(london, 10, &cellstore.NearbyOptions{MaxDistance: 400e3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs).To(ContainCells(london, paris))
+
+		ent := rs.Entries[1]
+		Expect(ent.Meters()).To(BeNumerically("~", 342165, 1))
+		Expect(ent.Kilometers()).To(BeNumerically("~", 342.16, 0.01))
+		Expect(ent.Bearing.Degrees()).To(BeNumerically("~", 148.1, 0.1))
+		Expect(rs.Entries[0].Bearing).To(BeZero())
+
+		rs, err = subject.NearbyWithOptions(paris, 10, &cellstore.NearbyOptions{MaxDistance: 400e3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs).To(ContainCells(paris, london))
+		Expect(rs.Entries[1].Bearing.Degrees()).To(BeNumerically("~", 330.0, 0.1))
+	})
+
+	It("should find nearby across face boundaries", func() {
+		entries := make(map[s2.CellID]string)
+		for lat := -1.0; lat <= 1.0; lat += 0.1 {
+			for lng := 43.0; lng < 44.9; lng += 0.1 {
+				entries[cellIDFromDegrees(lat, lng)] = "face-0"
+			}
+		}
+		target := cellIDFromDegrees(0, 45.01)
+		entries[target] = "face-1"
+		subject = seedStore(entries)
+
+		origin := cellIDFromDegrees(0, 44.99)
+		Expect(origin.Face()).To(Equal(0))
+		Expect(target.Face()).To(Equal(1))
+
+		rs, err := subject.NearbyWithOptions(origin, 3, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(3))
+		Expect(rs.Entries[0].CellID).NotTo(Equal(target))
+
+		rs, err = subject.NearbyWithOptions(origin, 3, &cellstore.NearbyOptions{Neighbors: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(3))
+		Expect(rs.Entries[0].CellID).To(Equal(target))
+		Expect(rs.Entries[0].Meters()).To(BeNumerically("~", 2224, 1))
+
+		face := func(value []byte) []byte { return value }
+		rs, err = subject.NearbyWithOptions(origin, 2, &cellstore.NearbyOptions{Neighbors: true, GroupBy: face})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Len()).To(Equal(2))
+		Expect(rs.Entries[0].CellID).To(Equal(target))
+		Expect(string(rs.Entries[1].Value)).To(Equal("face-0"))
+	})
+
+	It("should find nearby points", func() {
+		london := cellIDFromDegrees(51.5, -0.12)
+		paris := cellIDFromDegrees(48.86, 2.35)
+		berlin := cellIDFromDegrees(52.52, 13.4)
+		subject = seedStore(map[s2.CellID]string{london: "London", paris: "Paris", berlin: "Berlin"})
+
+		ll := s2.LatLngFromDegrees(51.5, -0.12)
+		rs, err := subject.NearbyLatLng(ll, 10)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs).To(ContainCells(london, paris, berlin))
+		Expect(rs.Entries[0].Distance).To(Equal(s2.PointFromLatLng(ll).Distance(london.Point())))
+		Expect(rs.Entries[1].Distance).To(Equal(s2.PointFromLatLng(ll).Distance(paris.Point())))
+		Expect(rs.Entries[1].Bearing.Degrees()).To(BeNumerically("~", 148.1, 0.1))
+
+		pt := s2.PointFromLatLng(s2.LatLngFromDegrees(48.8, 2.3))
+		rs, err = subject.NearbyPoint(pt, 1)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs).To(ContainCells(paris))
+		Expect(rs.Entries[0].Meters()).To(BeNumerically("~", 7610, 1))
+
+		rs, err = subject.NearbyPointContext(context.Background(), pt, 10, &cellstore.NearbyOptions{MaxDistance: 400e3})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(rs).To(ContainCells(paris, london))
+	})
+
 	It("should reject invalid cell IDs", func() {
 		_, err := subject.FindSection(1317624576600000002)
 		Expect(err).To(MatchError(`cellstore: invalid cell ID`))
@@ -260,3 +399,46 @@ func BenchmarkReader_Nearby(b *testing.B) {
 		runBench(b, 10e6, 100, sntable.SnappyCompression)
 	})
 }
+
+func BenchmarkReader_NearbyInto(b *testing.B) {
+	runBench := func(b *testing.B, numRecords int, limit int, compression sntable.Compression) {
+		fname, err := createSeeds(numRecords, compression)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer os.Remove(fname)
+
+		r, closer, err := openSeeds(fname)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer closer.Close()
+
+		ctx := context.Background()
+		rs := new(cellstore.NearbyRS)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			cellID := seedCellID + s2.CellID((i%numRecords)*8)
+			if err := r.NearbyInto(ctx, rs, cellID, limit, nil); err != nil {
+				b.Fatal(err)
+			} else if n := rs.Len(); n != limit {
+				b.Fatalf("unable to iterate across %d, expected %d entries but got %d", cellID, limit, n)
+			}
+		}
+	}
+
+	b.Run("limit:1 plain", func(b *testing.B) {
+		runBench(b, 10e6, 1, sntable.NoCompression)
+	})
+	b.Run("limit:20 plain", func(b *testing.B) {
+		runBench(b, 10e6, 20, sntable.NoCompression)
+	})
+	b.Run("limit:1 snappy", func(b *testing.B) {
+		runBench(b, 10e6, 1, sntable.SnappyCompression)
+	})
+	b.Run("limit:20 snappy", func(b *testing.B) {
+		runBench(b, 10e6, 20, sntable.SnappyCompression)
+	})
+}
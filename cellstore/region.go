@@ -0,0 +1,220 @@
+package cellstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/geo/r1"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// RectFromCorners creates a rectangle from its south-west and north-east
+// corners, as commonly used by map viewports. Rectangles where the west
+// longitude is greater than the east longitude cross the antimeridian.
+func RectFromCorners(sw, ne s2.LatLng) s2.Rect {
+	return s2.Rect{
+		Lat: r1.IntervalFromPoint(sw.Lat.Radians()).AddPoint(ne.Lat.Radians()),
+		Lng: s1.IntervalFromEndpoints(sw.Lng.Radians(), ne.Lng.Radians()),
+	}
+}
+
+// WithinRect returns entries inside the rectangle, sorted by cell ID.
+// Rectangles crossing the antimeridian are supported. An optional limit
+// caps the number of returned entries, the result set is marked as
+// Truncated if more entries could be found. Distances of the returned
+// entries are not calculated.
+func (r *Reader) WithinRect(rect s2.Rect, limit int) (*NearbyRS, error) {
+	return r.WithinRectContext(context.Background(), rect, limit)
+}
+
+// WithinRectContext is like WithinRect but aborts the search with
+// ctx.Err() once the context is cancelled.
+func (r *Reader) WithinRectContext(ctx context.Context, rect s2.Rect, limit int) (*NearbyRS, error) {
+	return r.withinRect(ctx, rect, limit, 0)
+}
+
+// ResumeWithinRect returns the next page of a WithinRect query. The
+// token must be taken from the result set of the previous page.
+func (r *Reader) ResumeWithinRect(rect s2.Rect, limit int, token Token) (*NearbyRS, error) {
+	return r.ResumeWithinRectContext(context.Background(), rect, limit, token)
+}
+
+// ResumeWithinRectContext is like ResumeWithinRect but aborts the search
+// with ctx.Err() once the context is cancelled.
+func (r *Reader) ResumeWithinRectContext(ctx context.Context, rect s2.Rect, limit int, token Token) (*NearbyRS, error) {
+	last, err := parseCellIDToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return r.withinRect(ctx, rect, limit, last)
+}
+
+func (r *Reader) withinRect(ctx context.Context, rect s2.Rect, limit int, after s2.CellID) (*NearbyRS, error) {
+	qs := r.newQueryStats("WithinRect")
+	defer r.observe(qs)
+
+	rs := newNearbyRS()
+	rs.Earth = r.earth
+	if rect.IsEmpty() {
+		return rs, nil
+	}
+
+	if err := r.scanRegion(ctx, &rect, after, qs, func(cellID s2.CellID, value []byte) error {
+		if limit > 0 && rs.Len() == limit {
+			rs.Truncated = true
+			return errStopScan
+		}
+		rs.add(cellID, value, 0)
+		return nil
+	}); err != nil && err != errStopScan {
+		rs.Release()
+		return nil, err
+	}
+
+	if rs.Truncated {
+		rs.Token = appendCellIDToken(rs.Token[:0], rs.Entries[rs.Len()-1].CellID)
+	}
+	if qs != nil {
+		qs.EntriesReturned = rs.Len()
+	}
+	return rs, nil
+}
+
+// scanRange calls fn for each entry between min and max (both inclusive).
+// The context is checked for cancellation between sections.
+func (r *Reader) scanRange(ctx context.Context, min, max s2.CellID, qs *QueryStats, fn func(s2.CellID, []byte) error) error {
+	iter, err := r.findSection(uint64(min), qs)
+	if err != nil {
+		return err
+	}
+	defer iter.Release()
+
+	iter.s.Seek(uint64(min))
+	for {
+		for iter.Next() {
+			cellID := iter.CellID()
+			if cellID > max {
+				return nil
+			}
+			if err := fn(cellID, iter.Value()); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !iter.NextSection() {
+			break
+		}
+	}
+	return iter.Err()
+}
+
+// scanRegion calls fn for each entry which intersects the region,
+// optionally skipping all entries up to and including after.
+func (r *Reader) scanRegion(ctx context.Context, region s2.Region, after s2.CellID, qs *QueryStats, fn func(s2.CellID, []byte) error) error {
+	return r.scanCovering(ctx, coverRegion(region), nil, after, qs, func(cellID s2.CellID, value []byte) error {
+		if !intersectsRegion(region, cellID) {
+			return nil
+		}
+		return fn(cellID, value)
+	})
+}
+
+// scanCovering calls fn for each entry within the covering and for each
+// stored ancestor of the covering cells, in key order. Entries up to and
+// including after are skipped, as are ancestors which were covered by
+// skip already.
+func (r *Reader) scanCovering(ctx context.Context, cu, skip s2.CellUnion, after s2.CellID, qs *QueryStats, fn func(s2.CellID, []byte) error) error {
+	ranges := coverRanges(cu)
+	keys := ancestorKeys(cu, ranges, skip, after)
+
+	for len(ranges) != 0 || len(keys) != 0 {
+		// look up ancestors located before the next range
+		n := 0
+		for n < len(keys) && (len(ranges) == 0 || keys[n] < ranges[0][0]) {
+			n++
+		}
+		if n != 0 {
+			if err := r.lookupKeys(ctx, keys[:n], qs, fn); err != nil {
+				return err
+			}
+			keys = keys[n:]
+			continue
+		}
+
+		rng := ranges[0]
+		ranges = ranges[1:]
+		if rng[1] <= after {
+			continue
+		} else if rng[0] <= after {
+			rng[0] = after + 1
+		}
+
+		if err := r.scanRange(ctx, rng[0], rng[1], qs, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ancestorKeys returns the sorted ancestors of the covering cells, which
+// are located outside of the covering ranges and after after. Ancestors
+// of cells in skip are excluded.
+func ancestorKeys(cu s2.CellUnion, ranges [][2]s2.CellID, skip s2.CellUnion, after s2.CellID) []s2.CellID {
+	var keys []s2.CellID
+	for _, c := range cu {
+		for level := c.Level() - 1; level >= 0; level-- {
+			a := c.Parent(level)
+			if a <= after || skip.IntersectsCellID(a) {
+				continue
+			}
+
+			i := sort.Search(len(ranges), func(i int) bool { return ranges[i][1] >= a })
+			if i < len(ranges) && ranges[i][0] <= a {
+				continue // found by range scans
+			}
+			keys = append(keys, a)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	n := 0
+	for i, k := range keys {
+		if i == 0 || k != keys[n-1] {
+			keys[n] = k
+			n++
+		}
+	}
+	return keys[:n]
+}
+
+// intersectsRegion returns true if a stored cell intersects the region.
+// Leaf cells are treated as points.
+func intersectsRegion(region s2.Region, cellID s2.CellID) bool {
+	if cellID.IsLeaf() {
+		return region.ContainsPoint(cellID.Point())
+	}
+	return region.IntersectsCell(s2.CellFromCellID(cellID))
+}
+
+func coverRegion(region s2.Region) s2.CellUnion {
+	rc := &s2.RegionCoverer{MaxLevel: s2.MaxLevel, MaxCells: 16}
+	return rc.Covering(region)
+}
+
+// coverRanges converts a normalized cell union into a list of [min, max]
+// ranges, merging adjacent cells into a single range.
+func coverRanges(cu s2.CellUnion) [][2]s2.CellID {
+	ranges := make([][2]s2.CellID, 0, len(cu))
+	for _, c := range cu {
+		min, max := c.RangeMin(), c.RangeMax()
+		if n := len(ranges); n != 0 && ranges[n-1][1].Next() == min {
+			ranges[n-1][1] = max
+			continue
+		}
+		ranges = append(ranges, [2]s2.CellID{min, max})
+	}
+	return ranges
+}
@@ -0,0 +1,102 @@
+package cellstore_test
+
+import (
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("WithinRect", func() {
+	var subject *cellstore.Reader
+
+	london := cellIDFromDegrees(51.5, -0.12)
+	paris := cellIDFromDegrees(48.86, 2.35)
+	suva := cellIDFromDegrees(-18.14, 178.44)
+	taveuni := cellIDFromDegrees(-16.85, -179.97)
+	apia := cellIDFromDegrees(-13.83, -171.76)
+
+	withinRect := func(sw, ne s2.LatLng, limit int) ([]s2.CellID, bool, error) {
+		rs, err := subject.WithinRect(cellstore.RectFromCorners(sw, ne), limit)
+		if err != nil {
+			return nil, false, err
+		}
+		defer rs.Release()
+
+		var cellIDs []s2.CellID
+		for _, ent := range rs.Entries {
+			cellIDs = append(cellIDs, ent.CellID)
+		}
+		return cellIDs, rs.Truncated, nil
+	}
+
+	BeforeEach(func() {
+		subject = seedStore(map[s2.CellID]string{
+			london:  "London",
+			paris:   "Paris",
+			suva:    "Suva",
+			taveuni: "Taveuni",
+			apia:    "Apia",
+		})
+	})
+
+	It("should find entries", func() {
+		cellIDs, truncated, err := withinRect(s2.LatLngFromDegrees(48, -1), s2.LatLngFromDegrees(52, 3), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(Equal([]s2.CellID{paris, london}))
+		Expect(truncated).To(BeFalse())
+
+		cellIDs, _, err = withinRect(s2.LatLngFromDegrees(50, -1), s2.LatLngFromDegrees(52, 3), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(Equal([]s2.CellID{london}))
+
+		cellIDs, _, err = withinRect(s2.LatLngFromDegrees(0, 0), s2.LatLngFromDegrees(10, 10), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(BeEmpty())
+	})
+
+	It("should find intersecting areas", func() {
+		subject = seedStore(map[s2.CellID]string{
+			london.Parent(5):  "region",
+			london.Parent(10): "city",
+			london.Parent(12): "district",
+			london:            "London",
+			paris.Parent(10):  "Paris",
+		})
+
+		ll := london.LatLng()
+		rect := s2.RectFromCenterSize(ll, s2.LatLngFromDegrees(0.002, 0.002))
+		Expect(rect.ContainsPoint(london.Parent(12).Point())).To(BeFalse())
+
+		cellIDs, _, err := withinRect(rect.Lo(), rect.Hi(), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(Equal([]s2.CellID{london.Parent(5), london, london.Parent(12), london.Parent(10)}))
+	})
+
+	It("should support rectangles crossing the antimeridian", func() {
+		cellIDs, _, err := withinRect(s2.LatLngFromDegrees(-20, 178), s2.LatLngFromDegrees(-15, -179), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(ConsistOf(suva, taveuni))
+
+		cellIDs, _, err = withinRect(s2.LatLngFromDegrees(-20, 179), s2.LatLngFromDegrees(-10, -170), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(ConsistOf(taveuni, apia))
+
+		// the complement, not crossing the antimeridian
+		cellIDs, _, err = withinRect(s2.LatLngFromDegrees(-20, -179), s2.LatLngFromDegrees(-15, 178), 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(BeEmpty())
+	})
+
+	It("should truncate results", func() {
+		cellIDs, truncated, err := withinRect(s2.LatLngFromDegrees(-20, 178), s2.LatLngFromDegrees(-10, -170), 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(HaveLen(2))
+		Expect(truncated).To(BeTrue())
+
+		cellIDs, truncated, err = withinRect(s2.LatLngFromDegrees(-20, 178), s2.LatLngFromDegrees(-10, -170), 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cellIDs).To(HaveLen(3))
+		Expect(truncated).To(BeFalse())
+	})
+})
@@ -0,0 +1,438 @@
+package cellstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/geo/s2"
+)
+
+// LoadFunc loads a reader, e.g. from a file.
+type LoadFunc func() (*Reader, error)
+
+// ReloadOptions define Reloadable specific options.
+type ReloadOptions struct {
+	// Check is an optional function to validate a newly loaded reader
+	// before it is swapped in.
+	Check func(*Reader) error
+}
+
+func (o *ReloadOptions) norm() *ReloadOptions {
+	var oo ReloadOptions
+	if o != nil {
+		oo = *o
+	}
+	return &oo
+}
+
+// Reloadable wraps a Reader which can be replaced atomically while queries
+// are in progress. Replaced readers are closed once all pending queries have
+// completed and all result sets and iterators obtained from them are
+// released. Reloadable is safe for concurrent use.
+type Reloadable struct {
+	*reloadState
+	path []string // layer names, see Layer
+}
+
+// reloadState is shared by a Reloadable and its layers.
+type reloadState struct {
+	load  LoadFunc
+	check func(*Reader) error
+
+	reload sync.Mutex // serialises reloads
+	closed bool       // guarded by reload
+	mu     sync.RWMutex
+	cur    *reloadHandle
+}
+
+// NewReloadable loads the initial reader and returns a Reloadable.
+func NewReloadable(load LoadFunc, o *ReloadOptions) (*Reloadable, error) {
+	o = o.norm()
+	rr := &Reloadable{reloadState: &reloadState{load: load, check: o.Check}}
+	if err := rr.Reload(); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// Reload loads and checks a new reader and swaps it in. Queries are served
+// by the current reader until the swap. If loading or checking fails, the
+// current reader is retained. Reload blocks until the new reader is
+// loaded, periodic reloads should be run in a separate goroutine, e.g.
+// on a time.Ticker. Reloads fail once the Reloadable is closed. Reloads
+// of layers reload the entire file.
+func (rr *Reloadable) Reload() error {
+	rr.reload.Lock()
+	defer rr.reload.Unlock()
+
+	if rr.closed {
+		return errClosed
+	}
+
+	r, err := rr.load()
+	if err != nil {
+		return err
+	}
+	if rr.check != nil {
+		if err := rr.check(r); err != nil {
+			_ = r.Close()
+			return err
+		}
+	}
+
+	rr.mu.Lock()
+	old := rr.cur
+	rr.cur = &reloadHandle{r: r, refs: 1}
+	rr.mu.Unlock()
+
+	old.release()
+	return nil
+}
+
+// Close closes the current reader once all pending queries have completed.
+// Subsequent queries and reloads fail. Closing a layer closes the entire
+// file.
+func (rr *Reloadable) Close() error {
+	rr.reload.Lock()
+	defer rr.reload.Unlock()
+
+	rr.closed = true
+
+	rr.mu.Lock()
+	old := rr.cur
+	rr.cur = nil
+	rr.mu.Unlock()
+
+	old.release()
+	return nil
+}
+
+// ContentHash returns the content hash of the current reader.
+func (rr *Reloadable) ContentHash() []byte {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil
+	}
+	defer h.release()
+	return r.ContentHash()
+}
+
+// Layers returns the names of all layers of the current reader, sorted.
+func (rr *Reloadable) Layers() []string {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil
+	}
+	defer h.release()
+	return r.Layers()
+}
+
+// Layer returns a named layer. Queries are served by the layer of the
+// current reader and fail if a reloaded file doesn't contain the layer.
+// Layers share the state of rr and don't need to be closed.
+func (rr *Reloadable) Layer(name string) (*Reloadable, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer h.release()
+
+	if _, err := r.Layer(name); err != nil {
+		return nil, err
+	}
+
+	path := make([]string, 0, len(rr.path)+1)
+	path = append(path, rr.path...)
+	path = append(path, name)
+	return &Reloadable{reloadState: rr.reloadState, path: path}, nil
+}
+
+// Get returns the value of a single key.
+func (rr *Reloadable) Get(key uint64) ([]byte, error) {
+	return rr.Append(nil, key)
+}
+
+// Append retrieves the value of a single key and appends it to dst.
+func (rr *Reloadable) Append(dst []byte, key uint64) ([]byte, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return dst, err
+	}
+	defer h.release()
+	return r.Append(dst, key)
+}
+
+// Lookup returns the value of the range which contains cellID.
+func (rr *Reloadable) Lookup(cellID s2.CellID) ([]byte, error) {
+	return rr.AppendLookup(nil, cellID)
+}
+
+// AppendLookup appends the value of the range which contains cellID to dst.
+func (rr *Reloadable) AppendLookup(dst []byte, cellID s2.CellID) ([]byte, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return dst, err
+	}
+	defer h.release()
+	return r.AppendLookup(dst, cellID)
+}
+
+// FindSection returns an iterator of the section containing cellID.
+func (rr *Reloadable) FindSection(cellID s2.CellID) (*SectionIterator, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.sectionIterator(r.FindSection(cellID))
+}
+
+// ResumeSection resumes a section iterator from a token.
+func (rr *Reloadable) ResumeSection(token Token) (*SectionIterator, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.sectionIterator(r.ResumeSection(token))
+}
+
+// All returns an iterator over all entries, in key order.
+func (rr *Reloadable) All() *Iterator {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return &Iterator{err: err}
+	}
+	return h.iterator(r.All())
+}
+
+// Reverse returns an iterator over all entries with cell IDs less than or
+// equal to from, in reverse key order.
+func (rr *Reloadable) Reverse(from s2.CellID) *Iterator {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return &Iterator{err: err}
+	}
+	return h.iterator(r.Reverse(from))
+}
+
+// Nearby returns a limited result set of entries close to cellID.
+func (rr *Reloadable) Nearby(cellID s2.CellID, limit int) (*NearbyRS, error) {
+	return rr.NearbyContext(context.Background(), cellID, limit, nil)
+}
+
+// NearbyWithOptions is like Nearby but accepts custom options.
+func (rr *Reloadable) NearbyWithOptions(cellID s2.CellID, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	return rr.NearbyContext(context.Background(), cellID, limit, o)
+}
+
+// NearbyContext is like NearbyWithOptions but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) NearbyContext(ctx context.Context, cellID s2.CellID, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.NearbyContext(ctx, cellID, limit, o))
+}
+
+// NearbyInto is like NearbyContext but writes results into rs.
+func (rr *Reloadable) NearbyInto(ctx context.Context, rs *NearbyRS, cellID s2.CellID, limit int, o *NearbyOptions) error {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return err
+	}
+	defer h.release()
+	return r.NearbyInto(ctx, rs, cellID, limit, o)
+}
+
+// NearbyPoint returns a limited result set of entries close to p.
+func (rr *Reloadable) NearbyPoint(p s2.Point, limit int) (*NearbyRS, error) {
+	return rr.NearbyPointContext(context.Background(), p, limit, nil)
+}
+
+// NearbyLatLng returns a limited result set of entries close to ll.
+func (rr *Reloadable) NearbyLatLng(ll s2.LatLng, limit int) (*NearbyRS, error) {
+	return rr.NearbyPointContext(context.Background(), s2.PointFromLatLng(ll), limit, nil)
+}
+
+// NearbyPointContext is like NearbyPoint but accepts custom options and
+// aborts with ctx.Err() once the context is cancelled.
+func (rr *Reloadable) NearbyPointContext(ctx context.Context, p s2.Point, limit int, o *NearbyOptions) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.NearbyPointContext(ctx, p, limit, o))
+}
+
+// NearbyScored returns the entries close to p with the highest scores.
+func (rr *Reloadable) NearbyScored(p s2.Point, limit int, score ScoreFunc, o *ScoreOptions) (*NearbyRS, error) {
+	return rr.NearbyScoredContext(context.Background(), p, limit, score, o)
+}
+
+// NearbyScoredContext is like NearbyScored but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) NearbyScoredContext(ctx context.Context, p s2.Point, limit int, score ScoreFunc, o *ScoreOptions) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.NearbyScoredContext(ctx, p, limit, score, o))
+}
+
+// Containing returns all entries which contain cellID.
+func (rr *Reloadable) Containing(cellID s2.CellID) (*NearbyRS, error) {
+	return rr.ContainingContext(context.Background(), cellID)
+}
+
+// ContainingContext is like Containing but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) ContainingContext(ctx context.Context, cellID s2.CellID) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.ContainingContext(ctx, cellID))
+}
+
+// WithinRect returns a limited result set of entries within rect.
+func (rr *Reloadable) WithinRect(rect s2.Rect, limit int) (*NearbyRS, error) {
+	return rr.WithinRectContext(context.Background(), rect, limit)
+}
+
+// WithinRectContext is like WithinRect but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) WithinRectContext(ctx context.Context, rect s2.Rect, limit int) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.WithinRectContext(ctx, rect, limit))
+}
+
+// ResumeWithinRect resumes a WithinRect query from a token.
+func (rr *Reloadable) ResumeWithinRect(rect s2.Rect, limit int, token Token) (*NearbyRS, error) {
+	return rr.ResumeWithinRectContext(context.Background(), rect, limit, token)
+}
+
+// ResumeWithinRectContext is like ResumeWithinRect but aborts with
+// ctx.Err() once the context is cancelled.
+func (rr *Reloadable) ResumeWithinRectContext(ctx context.Context, rect s2.Rect, limit int, token Token) (*NearbyRS, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	return h.nearbyRS(r.ResumeWithinRectContext(ctx, rect, limit, token))
+}
+
+// Aggregate returns entry statistics within region, by parent cell.
+func (rr *Reloadable) Aggregate(region s2.Region, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	return rr.AggregateContext(context.Background(), region, level, reduce)
+}
+
+// AggregateContext is like Aggregate but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) AggregateContext(ctx context.Context, region s2.Region, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer h.release()
+	return r.AggregateContext(ctx, region, level, reduce)
+}
+
+// AggregateRange returns entry statistics within [min, max], by parent cell.
+func (rr *Reloadable) AggregateRange(min, max s2.CellID, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	return rr.AggregateRangeContext(context.Background(), min, max, level, reduce)
+}
+
+// AggregateRangeContext is like AggregateRange but aborts with ctx.Err()
+// once the context is cancelled.
+func (rr *Reloadable) AggregateRangeContext(ctx context.Context, min, max s2.CellID, level int, reduce Reducer) (map[s2.CellID]Stats, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer h.release()
+	return r.AggregateRangeContext(ctx, min, max, level, reduce)
+}
+
+// Group groups entries within region by the key extracted from their values.
+func (rr *Reloadable) Group(region s2.Region, key KeyFunc, reduce Reducer) (map[string]Stats, error) {
+	return rr.GroupContext(context.Background(), region, key, reduce)
+}
+
+// GroupContext is like Group but aborts with ctx.Err() once
+// the context is cancelled.
+func (rr *Reloadable) GroupContext(ctx context.Context, region s2.Region, key KeyFunc, reduce Reducer) (map[string]Stats, error) {
+	h, r, err := rr.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer h.release()
+	return r.GroupContext(ctx, region, key, reduce)
+}
+
+// acquire acquires the current handle along with the reader of the layer.
+func (rr *Reloadable) acquire() (*reloadHandle, *Reader, error) {
+	rr.mu.RLock()
+	h := rr.cur
+	if h != nil {
+		atomic.AddInt64(&h.refs, 1)
+	}
+	rr.mu.RUnlock()
+
+	if h == nil {
+		return nil, nil, errClosed
+	}
+
+	r := h.r
+	for _, name := range rr.path {
+		lr, err := r.Layer(name)
+		if err != nil {
+			h.release()
+			return nil, nil, err
+		}
+		r = lr
+	}
+	return h, r, nil
+}
+
+// --------------------------------------------------------------------
+
+// reloadHandle is a reference counted reader.
+type reloadHandle struct {
+	r    *Reader
+	refs int64
+}
+
+// release releases a reference and closes the reader once
+// all references are released.
+func (h *reloadHandle) release() {
+	if h != nil && atomic.AddInt64(&h.refs, -1) == 0 {
+		_ = h.r.Close()
+	}
+}
+
+func (h *reloadHandle) nearbyRS(rs *NearbyRS, err error) (*NearbyRS, error) {
+	if err != nil {
+		h.release()
+		return nil, err
+	}
+	rs.release = h.release
+	return rs, nil
+}
+
+func (h *reloadHandle) sectionIterator(iter *SectionIterator, err error) (*SectionIterator, error) {
+	if err != nil {
+		h.release()
+		return nil, err
+	}
+	iter.release = h.release
+	return iter, nil
+}
+
+func (h *reloadHandle) iterator(iter *Iterator) *Iterator {
+	iter.release = h.release
+	return iter
+}
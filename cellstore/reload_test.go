@@ -0,0 +1,227 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"testing/fstest"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+// closeTrackingFS counts open files.
+type closeTrackingFS struct {
+	fs.FS
+	open int64
+}
+
+func (t *closeTrackingFS) Open(name string) (fs.File, error) {
+	f, err := t.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&t.open, 1)
+	return &closeTrackingFile{File: f, ReaderAt: f.(io.ReaderAt), open: &t.open}, nil
+}
+
+func (t *closeTrackingFS) NumOpen() int { return int(atomic.LoadInt64(&t.open)) }
+
+type closeTrackingFile struct {
+	fs.File
+	io.ReaderAt
+	open *int64
+}
+
+func (f *closeTrackingFile) Close() error {
+	atomic.AddInt64(f.open, -1)
+	return f.File.Close()
+}
+
+var _ = Describe("Reloadable", func() {
+	var subject *cellstore.Reloadable
+	var fsys *closeTrackingFS
+	var mfs fstest.MapFS
+
+	store := func(value string) []byte {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{
+			WriterOptions: sntable.WriterOptions{BlockSize: 256},
+			ContentHash:   true,
+		})
+		for i := 0; i < 8*100; i += 8 {
+			Expect(w.Append(uint64(seedCellID+s2.CellID(i)), []byte(value))).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	load := func() (*cellstore.Reader, error) {
+		return cellstore.OpenFS(fsys, "test.cs")
+	}
+
+	BeforeEach(func() {
+		mfs = fstest.MapFS{"test.cs": &fstest.MapFile{Data: store("v1")}}
+		fsys = &closeTrackingFS{FS: mfs}
+
+		var err error
+		subject, err = cellstore.NewReloadable(load, nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(subject.Close()).To(Succeed())
+		Expect(fsys.NumOpen()).To(Equal(0))
+	})
+
+	It("should swap readers", func() {
+		Expect(subject.Get(uint64(seedCellID))).To(Equal([]byte("v1")))
+		hash := subject.ContentHash()
+		Expect(hash).To(HaveLen(32))
+
+		mfs["test.cs"] = &fstest.MapFile{Data: store("v2")}
+		Expect(subject.Reload()).To(Succeed())
+		Expect(subject.Get(uint64(seedCellID))).To(Equal([]byte("v2")))
+		Expect(subject.ContentHash()).NotTo(Equal(hash))
+		Expect(fsys.NumOpen()).To(Equal(1))
+	})
+
+	It("should close replaced readers once released", func() {
+		rs, err := subject.Nearby(seedCellID, 5)
+		Expect(err).NotTo(HaveOccurred())
+		iter, err := subject.FindSection(seedCellID)
+		Expect(err).NotTo(HaveOccurred())
+		all := subject.All()
+
+		mfs["test.cs"] = &fstest.MapFile{Data: store("v2")}
+		Expect(subject.Reload()).To(Succeed())
+		Expect(fsys.NumOpen()).To(Equal(2))
+
+		Expect(iter.Next()).To(BeTrue())
+		Expect(iter.Value()).To(Equal([]byte("v1")))
+		Expect(all.Next()).To(BeTrue())
+		Expect(all.Value()).To(Equal([]byte("v1")))
+		Expect(string(rs.Entries[0].Value)).To(Equal("v1"))
+
+		rs.Release()
+		iter.Release()
+		Expect(fsys.NumOpen()).To(Equal(2))
+
+		all.Release()
+		all.Release()
+		Expect(fsys.NumOpen()).To(Equal(1))
+	})
+
+	It("should retain the current reader on failed checks", func() {
+		errBad := errors.New("bad store")
+		Expect(subject.Close()).To(Succeed())
+
+		var err error
+		subject, err = cellstore.NewReloadable(load, &cellstore.ReloadOptions{
+			Check: func(r *cellstore.Reader) error {
+				if v, err := r.Get(uint64(seedCellID)); err != nil {
+					return err
+				} else if string(v) == "bad" {
+					return errBad
+				}
+				return nil
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		mfs["test.cs"] = &fstest.MapFile{Data: store("bad")}
+		Expect(subject.Reload()).To(MatchError(errBad))
+		Expect(subject.Get(uint64(seedCellID))).To(Equal([]byte("v1")))
+		Expect(fsys.NumOpen()).To(Equal(1))
+
+		mfs["test.cs"] = &fstest.MapFile{Data: []byte("not a store")}
+		Expect(subject.Reload()).To(HaveOccurred())
+		Expect(subject.Get(uint64(seedCellID))).To(Equal([]byte("v1")))
+	})
+
+	It("should serve queries while reloading", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				for j := 0; j < 100; j++ {
+					rs, err := subject.Nearby(seedCellID+400, 10)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rs.Len()).To(Equal(10))
+					rs.Release()
+				}
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			Expect(subject.Reload()).To(Succeed())
+		}
+		wg.Wait()
+		Expect(fsys.NumOpen()).To(Equal(1))
+	})
+
+	It("should query layers", func() {
+		layered := func(layer string) []byte {
+			buf := new(bytes.Buffer)
+			w := cellstore.NewWriterWithOptions(buf, nil)
+			Expect(w.Append(uint64(seedCellID), []byte("main"))).To(Succeed())
+			lw, err := w.Layer(layer, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lw.Append(uint64(seedCellID), []byte(layer))).To(Succeed())
+			Expect(lw.Close()).To(Succeed())
+			Expect(w.Close()).To(Succeed())
+			return buf.Bytes()
+		}
+
+		Expect(subject.Layers()).To(BeEmpty())
+		_, err := subject.Layer("pois")
+		Expect(err).To(MatchError(`cellstore: unknown layer`))
+
+		mfs["test.cs"] = &fstest.MapFile{Data: layered("pois")}
+		Expect(subject.Reload()).To(Succeed())
+		Expect(subject.Layers()).To(Equal([]string{"pois"}))
+
+		pois, err := subject.Layer("pois")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pois.Get(uint64(seedCellID))).To(Equal([]byte("pois")))
+		Expect(subject.Get(uint64(seedCellID))).To(Equal([]byte("main")))
+
+		iter := pois.All()
+		Expect(iter.Next()).To(BeTrue())
+
+		mfs["test.cs"] = &fstest.MapFile{Data: layered("transit")}
+		Expect(subject.Reload()).To(Succeed())
+		Expect(iter.Value()).To(Equal([]byte("pois")))
+		Expect(fsys.NumOpen()).To(Equal(2))
+		iter.Release()
+		Expect(fsys.NumOpen()).To(Equal(1))
+
+		_, err = pois.Get(uint64(seedCellID))
+		Expect(err).To(MatchError(`cellstore: unknown layer`))
+	})
+
+	It("should fail after close", func() {
+		Expect(subject.Close()).To(Succeed())
+		_, err := subject.Get(uint64(seedCellID))
+		Expect(err).To(MatchError(`cellstore: reader is closed`))
+
+		iter := subject.All()
+		Expect(iter.Next()).To(BeFalse())
+		Expect(iter.Err()).To(MatchError(`cellstore: reader is closed`))
+		iter.Release()
+
+		Expect(subject.Reload()).To(MatchError(`cellstore: reader is closed`))
+		_, err = subject.Get(uint64(seedCellID))
+		Expect(err).To(MatchError(`cellstore: reader is closed`))
+		Expect(subject.Close()).To(Succeed())
+		Expect(fsys.NumOpen()).To(Equal(0))
+	})
+})
@@ -0,0 +1,190 @@
+package cellstore
+
+import (
+	"context"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// ScoreFunc scores an entry by its distance and value. Higher scores rank first.
+type ScoreFunc func(distance s1.Angle, value []byte) float64
+
+// DecayFunc maps distances to multipliers between 0 and 1. Decay functions
+// must not increase with distance.
+type DecayFunc func(distance s1.Angle) float64
+
+// LinearDecay decays linearly from 1 at the origin to 0 at scale, in metres.
+// Scales <= 0 decay to 0 immediately.
+func (e Earth) LinearDecay(scale float64) DecayFunc {
+	a := e.Angle(scale)
+	if a <= 0 {
+		return stepDecay
+	}
+	return func(distance s1.Angle) float64 {
+		if distance >= a {
+			return 0
+		}
+		return 1 - float64(distance/a)
+	}
+}
+
+// ExpDecay decays exponentially, halving every scale, in metres.
+// Scales <= 0 decay to 0 immediately.
+func (e Earth) ExpDecay(scale float64) DecayFunc {
+	a := e.Angle(scale)
+	if a <= 0 {
+		return stepDecay
+	}
+	return func(distance s1.Angle) float64 {
+		return math.Exp(-math.Ln2 * float64(distance/a))
+	}
+}
+
+// GaussDecay decays along a gaussian curve, reaching 0.5 at scale, in metres.
+// Scales <= 0 decay to 0 immediately.
+func (e Earth) GaussDecay(scale float64) DecayFunc {
+	a := e.Angle(scale)
+	if a <= 0 {
+		return stepDecay
+	}
+	return func(distance s1.Angle) float64 {
+		x := float64(distance / a)
+		return math.Exp(-math.Ln2 * x * x)
+	}
+}
+
+// MaxScore returns a bound for ScoreOptions.MaxScore, matching scores of
+// Weighted with the decay and weights of at most maxWeight.
+func (d DecayFunc) MaxScore(maxWeight float64) func(distance s1.Angle) float64 {
+	return func(distance s1.Angle) float64 {
+		return maxWeight * d(distance)
+	}
+}
+
+// stepDecay is 1 at the origin and 0 elsewhere.
+func stepDecay(distance s1.Angle) float64 {
+	if distance <= 0 {
+		return 1
+	}
+	return 0
+}
+
+// Weighted returns a ScoreFunc which multiplies the weight of a value with
+// the decayed distance. A nil weight function weighs all values as 1.
+func Weighted(decay DecayFunc, weight func(value []byte) float64) ScoreFunc {
+	return func(distance s1.Angle, value []byte) float64 {
+		if weight == nil {
+			return decay(distance)
+		}
+		return weight(value) * decay(distance)
+	}
+}
+
+// ScoreOptions define optional parameters for scored searches.
+type ScoreOptions struct {
+	// MaxScore returns the maximum possible score of an entry at
+	// the given distance and must not increase with distance, see
+	// DecayFunc.MaxScore. It allows to terminate the search once no
+	// further entry can beat the current results.
+	// Default: nil (all entries within MaxDistance are scored)
+	MaxScore func(distance s1.Angle) float64
+
+	// MaxDistance limits results to entries within the given
+	// distance in metres, as measured on Earth.
+	// Default: 0 (unlimited)
+	MaxDistance float64
+
+	// Earth is the model used to convert metric distances.
+	// Default: the Earth of the reader
+	Earth Earth
+
+	// Radius is the radius of the initial search area in metres. The
+	// area grows with each iteration until the search can terminate.
+	// Default: 1000
+	Radius float64
+}
+
+func (o *ScoreOptions) norm() *ScoreOptions {
+	var oo ScoreOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.Radius <= 0 {
+		oo.Radius = 1000
+	}
+	return &oo
+}
+
+// NearbyScored returns the limit entries with the highest scores around p,
+// sorted by score. Without ScoreOptions.MaxScore or MaxDistance, the whole
+// table is scanned.
+func (r *Reader) NearbyScored(p s2.Point, limit int, score ScoreFunc, o *ScoreOptions) (*NearbyRS, error) {
+	return r.NearbyScoredContext(context.Background(), p, limit, score, o)
+}
+
+// NearbyScoredContext is like NearbyScored but aborts the search with
+// ctx.Err() once the context is cancelled.
+func (r *Reader) NearbyScoredContext(ctx context.Context, p s2.Point, limit int, score ScoreFunc, o *ScoreOptions) (*NearbyRS, error) {
+	o = o.norm()
+
+	qs := r.newQueryStats("NearbyScored")
+	defer r.observe(qs)
+
+	rs := newNearbyRS()
+	rs.byScore = true
+	if limit < 1 {
+		return rs, nil
+	}
+
+	earth := o.Earth.or(r.earth)
+	rs.Earth = earth
+
+	maxDist := s1.Angle(math.Pi)
+	if o.MaxDistance > 0 && earth.Angle(o.MaxDistance) < maxDist {
+		maxDist = earth.Angle(o.MaxDistance)
+	}
+
+	// without a bound, all entries within max distance must be scanned
+	radius := maxDist
+	if o.MaxScore != nil && earth.Angle(o.Radius) < radius {
+		radius = earth.Angle(o.Radius)
+	}
+
+	fn := func(cellID s2.CellID, value []byte) error {
+		if dist := cellDistance(cellID, p); dist <= maxDist {
+			rs.push(&NearbyEntry{CellID: cellID, Value: value, Distance: dist, Score: score(dist, value)}, limit)
+		}
+		return nil
+	}
+
+	// scan growing areas, skipping cells which were already scanned
+	var scanned s2.CellUnion
+	for {
+		covering := coverRegion(s2.CapFromCenterAngle(p, radius))
+		if err := r.scanCovering(ctx, s2.CellUnionFromDifference(covering, scanned), scanned, 0, qs, fn); err != nil {
+			rs.Release()
+			return nil, err
+		}
+
+		if radius >= maxDist {
+			break
+		}
+		if o.MaxScore != nil && rs.minScore(limit) >= o.MaxScore(radius) {
+			break
+		}
+
+		scanned = s2.CellUnionFromUnion(scanned, covering)
+		if radius *= 4; radius > maxDist {
+			radius = maxDist
+		}
+	}
+
+	rs.sort()
+	rs.calcBearings(s2.LatLngFromPoint(p))
+	if qs != nil {
+		qs.EntriesReturned = rs.Len()
+	}
+	return rs, nil
+}
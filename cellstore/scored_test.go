@@ -0,0 +1,156 @@
+package cellstore_test
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("NearbyScored", func() {
+	var subject *cellstore.Reader
+	var observer *mockObserver
+
+	origin := s2.PointFromLatLng(s2.LatLngFromDegrees(51.5, -0.12))
+	km := cellstore.DefaultEarth.Angle(1000)
+	decay := cellstore.DefaultEarth.ExpDecay(1000)
+
+	weight := func(value []byte) float64 {
+		w, _ := strconv.ParseFloat(string(value), 64)
+		return w
+	}
+	score := cellstore.Weighted(decay, weight)
+
+	BeforeEach(func() {
+		b := cellstore.NewBuilder(nil)
+		defer b.Close()
+
+		n := 0
+		for lat := 51.0; lat < 52.0; lat += 0.01 {
+			for lng := -0.6; lng < 0.4; lng += 0.01 {
+				n++
+				Expect(b.Append(cellIDFromDegrees(lat, lng), []byte(strconv.Itoa(n%10+1)))).To(Succeed())
+			}
+		}
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{WriterOptions: sntable.WriterOptions{BlockSize: 1024}})
+		Expect(b.Build(w)).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		observer = new(mockObserver)
+		var err error
+		subject, err = cellstore.NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &cellstore.ReaderOptions{
+			Observer: observer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	bruteForce := func(limit int) []s2.CellID {
+		type scored struct {
+			cellID s2.CellID
+			score  float64
+		}
+
+		var all []scored
+		it := subject.All()
+		defer it.Release()
+		for it.Next() {
+			dist := it.CellID().Point().Distance(origin)
+			all = append(all, scored{cellID: it.CellID(), score: score(dist, it.Value())})
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+
+		res := make([]s2.CellID, 0, limit)
+		for _, s := range all[:limit] {
+			res = append(res, s.cellID)
+		}
+		return res
+	}
+
+	cellIDs := func(rs *cellstore.NearbyRS) []s2.CellID {
+		res := make([]s2.CellID, 0, rs.Len())
+		for _, ent := range rs.Entries {
+			res = append(res, ent.CellID)
+		}
+		return res
+	}
+
+	It("should decay", func() {
+		earth := cellstore.DefaultEarth
+		Expect(earth.LinearDecay(1000)(0)).To(Equal(1.0))
+		Expect(earth.LinearDecay(1000)(km / 4)).To(BeNumerically("~", 0.75, 1e-9))
+		Expect(earth.LinearDecay(1000)(2 * km)).To(Equal(0.0))
+		Expect(earth.ExpDecay(1000)(0)).To(Equal(1.0))
+		Expect(earth.ExpDecay(1000)(km)).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(earth.ExpDecay(1000)(2 * km)).To(BeNumerically("~", 0.25, 1e-9))
+		Expect(earth.GaussDecay(1000)(0)).To(Equal(1.0))
+		Expect(earth.GaussDecay(1000)(km)).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(earth.GaussDecay(1000)(2 * km)).To(BeNumerically("~", 0.0625, 1e-9))
+		for _, decay := range []cellstore.DecayFunc{
+			earth.LinearDecay(0), earth.ExpDecay(0), earth.GaussDecay(0), earth.ExpDecay(-1000),
+		} {
+			Expect(decay(0)).To(Equal(1.0))
+			Expect(decay(km)).To(Equal(0.0))
+		}
+		Expect(cellstore.Weighted(earth.LinearDecay(1000), nil)(km/2, nil)).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(decay.MaxScore(10)(km)).To(BeNumerically("~", 5, 1e-9))
+
+		// scales are measured on the given model
+		big := cellstore.Earth{Radius: 2 * earth.Radius}
+		Expect(big.ExpDecay(2000)(km)).To(BeNumerically("~", 0.5, 1e-9))
+	})
+
+	It("should rank by score", func() {
+		rs, err := subject.NearbyScored(origin, 5, score, nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+
+		Expect(cellIDs(rs)).To(Equal(bruteForce(5)))
+		Expect(rs.Entries[0].Score).To(BeNumerically(">", rs.Entries[4].Score))
+		Expect(rs.Entries[0].Score).To(Equal(score(rs.Entries[0].Distance, rs.Entries[0].Value)))
+
+		// the closest entries are not the best ones
+		nearest, err := subject.NearbyPoint(origin, 5)
+		Expect(err).NotTo(HaveOccurred())
+		defer nearest.Release()
+		Expect(cellIDs(nearest)).NotTo(Equal(cellIDs(rs)))
+	})
+
+	It("should terminate early", func() {
+		rs, err := subject.NearbyScored(origin, 5, score, nil)
+		Expect(err).NotTo(HaveOccurred())
+		rs.Release()
+		Expect(observer.stats).To(HaveLen(1))
+		Expect(observer.stats[0].EntriesConsidered).To(Equal(10100))
+
+		observer.stats = observer.stats[:0]
+		rs, err = subject.NearbyScored(origin, 5, score, &cellstore.ScoreOptions{
+			MaxScore: decay.MaxScore(10),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+		Expect(cellIDs(rs)).To(Equal(bruteForce(5)))
+
+		Expect(observer.stats[0].Query).To(Equal("NearbyScored"))
+		Expect(observer.stats[0].EntriesConsidered).To(BeNumerically("<", 1000))
+		Expect(observer.stats[0].EntriesReturned).To(Equal(5))
+	})
+
+	It("should limit by distance", func() {
+		rs, err := subject.NearbyScored(origin, 100, score, &cellstore.ScoreOptions{MaxDistance: 2000})
+		Expect(err).NotTo(HaveOccurred())
+		defer rs.Release()
+
+		Expect(rs.Len()).To(BeNumerically(">", 10))
+		Expect(rs.Len()).To(BeNumerically("<", 100))
+		for _, ent := range rs.Entries {
+			Expect(ent.Meters()).To(BeNumerically("<=", 2000))
+		}
+	})
+})
@@ -1,6 +1,7 @@
 package cellstore
 
 import (
+	"bytes"
 	"encoding/binary"
 	"io"
 
@@ -12,6 +13,10 @@ import (
 type SorterOptions struct {
 	// An optional temporary directory. Default: os.TempDir()
 	TempDir string
+
+	// BufferSize limits the memory used for sorting before entries are
+	// spilled to disk. Default: 64MiB
+	BufferSize int
 }
 
 func (o *SorterOptions) norm() *SorterOptions {
@@ -23,33 +28,46 @@ func (o *SorterOptions) norm() *SorterOptions {
 }
 
 // Sorter allows to pre-sort entries to avoid out-of-order appends to Writer instances.
+// Values of the same cell are returned in the order they were appended.
 type Sorter struct {
-	x *extsort.Sorter
-	t []byte
+	x   *extsort.Sorter
+	t   []byte
+	seq uint64
 }
 
 // NewSorter creates a sorter.
 func NewSorter(o *SorterOptions) *Sorter {
 	o = o.norm()
 	return &Sorter{
-		x: extsort.New(&extsort.Options{WorkDir: o.TempDir}),
+		x: extsort.New(&extsort.Options{
+			WorkDir:    o.TempDir,
+			BufferSize: o.BufferSize,
+			Compare:    compareSorterEntries,
+		}),
 	}
 }
 
+// compareSorterEntries compares entries by cell ID and sequence number.
+func compareSorterEntries(a, b []byte) int {
+	return bytes.Compare(a[:16], b[:16])
+}
+
 // Append appends a cell to the sorter.
 func (s *Sorter) Append(cellID s2.CellID, data []byte) error {
 	if !cellID.IsValid() {
 		return errInvalidCellID
 	}
 
-	if sz := 8 + len(data); sz < cap(s.t) {
+	if sz := 16 + len(data); sz < cap(s.t) {
 		s.t = s.t[:sz]
 	} else {
 		s.t = make([]byte, sz)
 	}
 
 	binary.BigEndian.PutUint64(s.t[0:], uint64(cellID))
-	copy(s.t[8:], data)
+	binary.BigEndian.PutUint64(s.t[8:], s.seq)
+	copy(s.t[16:], data)
+	s.seq++
 	return s.x.Append(s.t)
 }
 
@@ -84,11 +102,11 @@ func (i *SorterIterator) NextEntry() (s2.CellID, [][]byte, error) {
 		i.nextID = s2.CellID(binary.BigEndian.Uint64(rawdata))
 
 		if currentID != 0 && currentID != i.nextID {
-			i.next = i.push(i.next, rawdata[8:])
+			i.next = i.push(i.next, rawdata[16:])
 			break
 		}
 		currentID = i.nextID
-		i.current = i.push(i.current, rawdata[8:])
+		i.current = i.push(i.current, rawdata[16:])
 	}
 
 	if err := i.it.Err(); err != nil {
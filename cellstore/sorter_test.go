@@ -51,4 +51,19 @@ var _ = Describe("Sorter", func() {
 		_, _, err = iter.NextEntry()
 		Expect(err).To(MatchError("EOF"))
 	})
+
+	It("should preserve the append order of values", func() {
+		Expect(subject.Append(seedCellID, []byte("z"))).To(Succeed())
+		Expect(subject.Append(seedCellID, []byte("zz"))).To(Succeed())
+		Expect(subject.Append(seedCellID, []byte("a"))).To(Succeed())
+		Expect(subject.Append(seedCellID, []byte("z"))).To(Succeed())
+
+		iter, err := subject.Sort()
+		Expect(err).NotTo(HaveOccurred())
+		defer iter.Close()
+
+		_, data, err := iter.NextEntry()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(data).To(Equal([][]byte{[]byte("z"), []byte("zz"), []byte("a"), []byte("z")}))
+	})
 })
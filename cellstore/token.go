@@ -0,0 +1,101 @@
+package cellstore
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Token is an opaque continuation token which allows to resume
+// a paginated query where the previous page ended.
+type Token []byte
+
+// ParseToken parses a token from its string representation.
+func ParseToken(s string) (Token, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errInvalidToken
+	}
+	return Token(b), nil
+}
+
+// String returns a URL-safe string representation of the token.
+func (t Token) String() string {
+	return base64.RawURLEncoding.EncodeToString(t)
+}
+
+const (
+	tokenKindCellID byte = 'c'
+	tokenKindNearby byte = 'n'
+)
+
+// appendCellIDToken appends a token which resumes after cellID.
+func appendCellIDToken(dst []byte, cellID s2.CellID) Token {
+	dst = append(dst, tokenKindCellID)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(cellID))
+	return dst
+}
+
+func parseCellIDToken(t Token) (s2.CellID, error) {
+	if len(t) != 9 || t[0] != tokenKindCellID {
+		return 0, errInvalidToken
+	}
+	return s2.CellID(binary.BigEndian.Uint64(t[1:])), nil
+}
+
+// nearbyToken holds the state of a paginated nearby query. Entries
+// within the window of the first page which rank up to the last entry
+// of the first page were returned by it. Subsequent pages consume entries
+// contiguously, outwards from the pivot.
+type nearbyToken struct {
+	Pivot    s2.CellID // the query pivot
+	Last     s2.CellID // the last entry of the first page
+	Distance s1.Angle  // the distance of the last entry of the first page
+	Min      s2.CellID // the left-most entry of the first page window
+	Max      s2.CellID // the right-most entry of the first page window
+	Left     s2.CellID // the left-most consumed entry
+	Right    s2.CellID // the right-most consumed entry
+}
+
+func (t *nearbyToken) AppendTo(dst []byte) Token {
+	dst = append(dst, tokenKindNearby)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Pivot))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Last))
+	dst = binary.BigEndian.AppendUint64(dst, math.Float64bits(float64(t.Distance)))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Min))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Max))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Left))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(t.Right))
+	return dst
+}
+
+// Returned returns true if an entry was returned by the first page.
+func (t *nearbyToken) Returned(cellID s2.CellID, distance s1.Angle) bool {
+	if cellID < t.Min || cellID > t.Max {
+		return false
+	}
+	return distance < t.Distance || (distance == t.Distance && cellID <= t.Last)
+}
+
+func parseNearbyToken(t Token) (*nearbyToken, error) {
+	if len(t) != 57 || t[0] != tokenKindNearby {
+		return nil, errInvalidToken
+	}
+
+	nt := &nearbyToken{
+		Pivot:    s2.CellID(binary.BigEndian.Uint64(t[1:])),
+		Last:     s2.CellID(binary.BigEndian.Uint64(t[9:])),
+		Distance: s1.Angle(math.Float64frombits(binary.BigEndian.Uint64(t[17:]))),
+		Min:      s2.CellID(binary.BigEndian.Uint64(t[25:])),
+		Max:      s2.CellID(binary.BigEndian.Uint64(t[33:])),
+		Left:     s2.CellID(binary.BigEndian.Uint64(t[41:])),
+		Right:    s2.CellID(binary.BigEndian.Uint64(t[49:])),
+	}
+	if nt.Min > nt.Max || nt.Left > nt.Pivot || nt.Right+1 < nt.Pivot {
+		return nil, errInvalidToken
+	}
+	return nt, nil
+}
@@ -0,0 +1,189 @@
+package cellstore_test
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Token", func() {
+	var subject *cellstore.Reader
+
+	BeforeEach(func() {
+		subject = seedInMem(100)
+	})
+
+	It("should parse", func() {
+		token, err := cellstore.ParseToken("YwAAAAAAAAAB")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.String()).To(Equal("YwAAAAAAAAAB"))
+
+		_, err = cellstore.ParseToken("")
+		Expect(err).To(MatchError(`cellstore: invalid token`))
+		_, err = cellstore.ParseToken("!")
+		Expect(err).To(MatchError(`cellstore: invalid token`))
+	})
+
+	It("should paginate nearby", func() {
+		first, err := subject.Nearby(1317624576600000281, 10)
+		Expect(err).NotTo(HaveOccurred())
+
+		var (
+			seen  = make(map[s2.CellID]bool)
+			token cellstore.Token
+			pages int
+		)
+		for pages = 0; pages == 0 || len(token) != 0; pages++ {
+			rs, err := subject.NearbyWithOptions(1317624576600000281, 10, &cellstore.NearbyOptions{Token: token})
+			Expect(err).NotTo(HaveOccurred())
+
+			if pages == 0 {
+				Expect(rs.Entries).To(Equal(first.Entries))
+			}
+
+			for i, ent := range rs.Entries {
+				Expect(seen).NotTo(HaveKey(ent.CellID))
+				seen[ent.CellID] = true
+
+				if i != 0 {
+					Expect(ent.Distance).To(BeNumerically(">=", rs.Entries[i-1].Distance))
+				}
+			}
+
+			// tokens are reused on Release, copy it
+			token = append(token[:0], rs.Token...)
+			rs.Release()
+		}
+		Expect(pages).To(Equal(10))
+		Expect(seen).To(HaveLen(100))
+	})
+
+	It("should paginate nearby as a single query", func() {
+		for _, pivot := range []s2.CellID{1317624576600000001, 1317624576600000281, 1317624576600000401, 1317624576600000793} {
+			for _, o := range []cellstore.NearbyOptions{{}, {Neighbors: true}, {MaxDistance: 1}} {
+				all, err := subject.NearbyWithOptions(pivot, 1000, &o)
+				Expect(err).NotTo(HaveOccurred())
+
+				var paged []s2.CellID
+				for {
+					rs, err := subject.NearbyWithOptions(pivot, 7, &o)
+					Expect(err).NotTo(HaveOccurred())
+					for _, ent := range rs.Entries {
+						paged = append(paged, ent.CellID)
+					}
+
+					o.Token = append(o.Token[:0], rs.Token...)
+					rs.Release()
+					if len(o.Token) == 0 {
+						break
+					}
+				}
+
+				expected := make([]s2.CellID, 0, all.Len())
+				for _, ent := range all.Entries {
+					expected = append(expected, ent.CellID)
+				}
+				Expect(paged).To(ConsistOf(expected))
+				all.Release()
+			}
+		}
+	})
+
+	It("should paginate nearby across randomly distributed entries", func() {
+		rnd := rand.New(rand.NewSource(1))
+		entries := make(map[s2.CellID]string, 2000)
+		for len(entries) < 2000 {
+			cellID := cellIDFromDegrees(50.5+2*rnd.Float64(), -1+2*rnd.Float64())
+			entries[cellID] = cellID.ToToken()
+		}
+		random := seedStore(entries)
+
+		cellIDs := make([]s2.CellID, 0, len(entries))
+		for cellID := range entries {
+			cellIDs = append(cellIDs, cellID)
+		}
+		sort.Slice(cellIDs, func(i, j int) bool { return cellIDs[i] < cellIDs[j] })
+
+		for _, pivot := range []s2.CellID{cellIDs[7], cellIDs[1396], cellIDs[1999]} {
+			for _, limit := range []int{5, 100} {
+				for _, o := range []cellstore.NearbyOptions{{}, {Neighbors: true}} {
+					seen := make(map[s2.CellID]int, len(entries))
+					for {
+						rs, err := random.NearbyWithOptions(pivot, limit, &o)
+						Expect(err).NotTo(HaveOccurred())
+						for _, ent := range rs.Entries {
+							seen[ent.CellID]++
+						}
+
+						o.Token = append(o.Token[:0], rs.Token...)
+						rs.Release()
+						if len(o.Token) == 0 {
+							break
+						}
+					}
+
+					Expect(seen).To(HaveLen(len(entries)), "pivot %d, limit %d, neighbors %v", pivot, limit, o.Neighbors)
+					for cellID, n := range seen {
+						Expect(n).To(Equal(1), "cell %d", cellID)
+					}
+				}
+			}
+		}
+	})
+
+	It("should reject nearby tokens from other queries", func() {
+		rs, err := subject.Nearby(1317624576600000281, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rs.Token).NotTo(BeEmpty())
+
+		_, err = subject.NearbyWithOptions(1317624576600000289, 10, &cellstore.NearbyOptions{Token: rs.Token})
+		Expect(err).To(MatchError(`cellstore: invalid token`))
+		_, err = subject.ResumeWithinRect(s2.FullRect(), 10, rs.Token)
+		Expect(err).To(MatchError(`cellstore: invalid token`))
+	})
+
+	It("should paginate rectangles", func() {
+		rect := s2.FullRect()
+
+		var cellIDs []s2.CellID
+		rs, err := subject.WithinRect(rect, 40)
+		Expect(err).NotTo(HaveOccurred())
+		for {
+			for _, ent := range rs.Entries {
+				cellIDs = append(cellIDs, ent.CellID)
+			}
+			if len(rs.Token) == 0 {
+				break
+			}
+
+			rs, err = subject.ResumeWithinRect(rect, 40, rs.Token)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(cellIDs).To(HaveLen(100))
+		Expect(cellIDs[0]).To(Equal(s2.CellID(1317624576600000001)))
+		Expect(cellIDs[40]).To(Equal(s2.CellID(1317624576600000321)))
+		Expect(cellIDs[99]).To(Equal(s2.CellID(1317624576600000793)))
+	})
+
+	It("should resume section iterators", func() {
+		iter, err := subject.FindSection(1317624576600000305)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(iter.Next()).To(BeTrue())
+		Expect(iter.Next()).To(BeTrue())
+		Expect(iter.CellID()).To(Equal(s2.CellID(1317624576600000313)))
+		token := iter.Token()
+		iter.Release()
+
+		iter, err = subject.ResumeSection(token)
+		Expect(err).NotTo(HaveOccurred())
+		defer iter.Release()
+
+		Expect(iter.Next()).To(BeTrue())
+		Expect(iter.CellID()).To(Equal(s2.CellID(1317624576600000321)))
+	})
+})
@@ -1,12 +1,215 @@
 package cellstore
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
 	"io"
 
 	"github.com/bsm/sntable"
 )
 
-// NewWriter wraps a writer and returns a sntable Writer.
+// WriterOptions define Writer specific options.
+type WriterOptions struct {
+	sntable.WriterOptions
+
+	// FilterFPRate enables bloom filters for each block with the given
+	// false-positive rate, e.g. 0.01. Filters allow exact key lookups to
+	// skip blocks which don't contain the key.
+	// Default: 0 (disabled)
+	FilterFPRate float64
+
+	// ContentHash records a SHA-256 hash of all keys and values in the
+	// file metadata, see Reader.ContentHash.
+	// Default: false
+	ContentHash bool
+}
+
+func (o *WriterOptions) norm() *WriterOptions {
+	var oo WriterOptions
+	if o != nil {
+		oo = *o
+	}
+	if oo.FilterFPRate < 0 || oo.FilterFPRate >= 1 {
+		oo.FilterFPRate = 0
+	}
+	return &oo
+}
+
+// Writer writes cellstore files.
+type Writer struct {
+	t *sntable.Writer
+	c *countingWriter
+	o *WriterOptions
+
+	keys    []uint64 // keys of the current block
+	filters []byte   // encoded filters
+	meta    meta     // additional metadata
+	hash    hash.Hash
+	tmp     []byte
+
+	tableSize int64               // set once the table is closed
+	layers    map[string][2]int64 // offsets and sizes of written layers
+	layer     *Writer             // currently open layer
+	parent    *Writer             // only set for layers
+	name      string              // layer name
+	offset    int64               // layer offset within the parent
+	closed    bool
+}
+
+// NewWriter wraps a writer and returns a sntable Writer. Use
+// NewWriterWithOptions for filters, metadata and layers.
 func NewWriter(w io.Writer, o *sntable.WriterOptions) *sntable.Writer {
 	return sntable.NewWriter(w, o)
 }
+
+// NewWriterWithOptions wraps a writer and returns a Writer with custom options.
+func NewWriterWithOptions(w io.Writer, o *WriterOptions) *Writer {
+	o = o.norm()
+	c := &countingWriter{w: w}
+	wr := &Writer{
+		t: sntable.NewWriter(c, &o.WriterOptions),
+		c: c,
+		o: o,
+	}
+	if o.ContentHash {
+		wr.hash = sha256.New()
+	}
+	return wr
+}
+
+// Append appends a key with a value. Keys must be appended in order.
+func (w *Writer) Append(key uint64, value []byte) error {
+	if w.tableSize != 0 {
+		return errTableClosed
+	}
+
+	n := w.c.n
+	if err := w.t.Append(key, value); err != nil {
+		return err
+	}
+
+	if w.o.FilterFPRate != 0 {
+		// a block was flushed before the key was added
+		if w.c.n != n {
+			w.flushFilter()
+		}
+		w.keys = append(w.keys, key)
+	}
+
+	if w.hash != nil {
+		w.tmp = binary.BigEndian.AppendUint64(w.tmp[:0], key)
+		w.tmp = binary.AppendUvarint(w.tmp, uint64(len(value)))
+		w.hash.Write(w.tmp)
+		w.hash.Write(value)
+	}
+	return nil
+}
+
+// Layer starts a named layer with custom options. Layers are separate key
+// spaces, stored in the same file, see Reader.Layer. Once the first layer is
+// started, no more entries can be appended to w. Each layer must be closed
+// before the next one is started and before w is closed.
+func (w *Writer) Layer(name string, o *WriterOptions) (*Writer, error) {
+	if w.closed {
+		return nil, errTableClosed
+	}
+	if w.layer != nil {
+		return nil, errLayerOpen
+	}
+	if _, ok := w.layers[name]; ok {
+		return nil, errDuplicateLayer
+	}
+	if err := w.closeTable(); err != nil {
+		return nil, err
+	}
+
+	lw := NewWriterWithOptions(w.c, o)
+	lw.parent, lw.name, lw.offset = w, name, w.c.n
+	w.layer = lw
+	return lw, nil
+}
+
+// Close closes the writer. Subsequent calls are no-ops.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if w.layer != nil {
+		return errLayerOpen
+	}
+	if err := w.closeTable(); err != nil {
+		return err
+	}
+
+	m := make(meta, len(w.meta)+4)
+	for k, v := range w.meta {
+		m[k] = v
+	}
+	if w.o.FilterFPRate != 0 {
+		m[metaFilters] = w.filters
+	}
+	if w.hash != nil {
+		m[metaContentHash] = w.hash.Sum(nil)
+	}
+	if len(w.layers) != 0 {
+		m[metaLayers] = appendLayers(nil, w.layers)
+		m[metaTableSize] = binary.AppendUvarint(nil, uint64(w.tableSize))
+	}
+	if len(m) != 0 {
+		if err := writeTrailer(w.c, w.c.n, m); err != nil {
+			return err
+		}
+	}
+
+	if p := w.parent; p != nil {
+		if p.layers == nil {
+			p.layers = make(map[string][2]int64)
+		}
+		p.layers[w.name] = [2]int64{w.offset, w.c.n}
+		p.layer = nil
+	}
+	w.closed = true
+	return nil
+}
+
+// closeTable closes the table and flushes the last filter.
+func (w *Writer) closeTable() error {
+	if w.tableSize != 0 {
+		return nil
+	}
+	if err := w.t.Close(); err != nil {
+		return err
+	}
+
+	if w.o.FilterFPRate != 0 && len(w.keys) != 0 {
+		w.flushFilter()
+	}
+	w.tableSize = w.c.n
+	return nil
+}
+
+func (w *Writer) setMeta(key string, value []byte) {
+	if w.meta == nil {
+		w.meta = make(meta)
+	}
+	w.meta[key] = value
+}
+
+func (w *Writer) flushFilter() {
+	w.filters = appendFilter(w.filters, newBloomFilter(w.keys, w.o.FilterFPRate))
+	w.keys = w.keys[:0]
+}
+
+// --------------------------------------------------------------------
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
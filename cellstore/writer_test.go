@@ -0,0 +1,110 @@
+package cellstore_test
+
+import (
+	"bytes"
+
+	"github.com/bsm/geokit/cellstore"
+	. "github.com/bsm/ginkgo/v2"
+	. "github.com/bsm/gomega"
+	"github.com/bsm/sntable"
+	"github.com/golang/geo/s2"
+)
+
+var _ = Describe("Writer", func() {
+	write := func(o *cellstore.WriterOptions) *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, o)
+		for i := 0; i < 8*1000; i += 8 {
+			Expect(w.Append(uint64(seedCellID+s2.CellID(i)), []byte("testdata"))).To(Succeed())
+		}
+		Expect(w.Close()).To(Succeed())
+		return buf
+	}
+
+	It("should write plain tables by default", func() {
+		buf := write(nil)
+
+		r, err := sntable.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Get(seedCellID + 800)).To(Equal([]byte("testdata")))
+	})
+
+	It("should close once", func() {
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{ContentHash: true})
+		Expect(w.Append(seedCellID, []byte("testdata"))).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+
+		size := buf.Len()
+		Expect(w.Close()).To(Succeed())
+		Expect(buf.Len()).To(Equal(size))
+		Expect(w.Append(seedCellID+8, []byte("testdata"))).To(MatchError(`cellstore: table is closed`))
+		_, err := w.Layer("a", nil)
+		Expect(err).To(MatchError(`cellstore: table is closed`))
+	})
+
+	It("should write filters", func() {
+		o := &cellstore.WriterOptions{FilterFPRate: 0.01}
+		o.BlockSize = 512
+		buf := write(o)
+
+		observer := new(mockObserver)
+		r, err := cellstore.NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &cellstore.ReaderOptions{
+			Observer: observer,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.NumBlocks()).To(Equal(22))
+
+		for i := 0; i < 8*1000; i += 8 {
+			Expect(r.Get(uint64(seedCellID + s2.CellID(i)))).To(Equal([]byte("testdata")))
+		}
+		Expect(observer.stats).To(HaveLen(1000))
+		Expect(observer.stats[0].FilterChecks).To(Equal(1))
+		Expect(observer.stats[0].FilterNegatives).To(Equal(0))
+		Expect(observer.stats[0].EntriesReturned).To(Equal(1))
+
+		observer.stats = observer.stats[:0]
+		for i := 0; i < 8*999; i += 8 {
+			_, err := r.Get(uint64(seedCellID + s2.CellID(i+2)))
+			Expect(err).To(MatchError(cellstore.ErrNotFound))
+		}
+
+		var checks, negatives, falsePositives, blocks int
+		for _, s := range observer.stats {
+			checks += s.FilterChecks
+			negatives += s.FilterNegatives
+			falsePositives += s.FilterFalsePositives
+			blocks += s.BlocksFetched
+		}
+		Expect(checks).To(Equal(999))
+		Expect(negatives + falsePositives).To(Equal(999))
+		Expect(falsePositives).To(BeNumerically("<", 30))
+		Expect(blocks).To(Equal(falsePositives))
+	})
+	It("should record content hashes", func() {
+		open := func(buf *bytes.Buffer) *cellstore.Reader {
+			r, err := cellstore.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			Expect(err).NotTo(HaveOccurred())
+			return r
+		}
+
+		Expect(open(write(nil)).ContentHash()).To(BeNil())
+
+		o := &cellstore.WriterOptions{ContentHash: true}
+		a := write(o)
+		Expect(write(o).Bytes()).To(Equal(a.Bytes()))
+
+		hash := open(a).ContentHash()
+		Expect(hash).To(HaveLen(32))
+
+		o.BlockSize = 512
+		o.FilterFPRate = 0.01
+		Expect(open(write(o)).ContentHash()).To(Equal(hash))
+
+		buf := new(bytes.Buffer)
+		w := cellstore.NewWriterWithOptions(buf, &cellstore.WriterOptions{ContentHash: true})
+		Expect(w.Append(uint64(seedCellID), []byte("testdata"))).To(Succeed())
+		Expect(w.Close()).To(Succeed())
+		Expect(open(buf).ContentHash()).NotTo(Equal(hash))
+	})
+})